@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+)
+
+const authorsUsage = "usage: quotemanager [-config file] authors duplicates [MIN_SIMILARITY] | merge INTO_ID ID..."
+
+// runAuthors runs the authors subcommand with its arguments.
+func runAuthors(storage *repositories.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing authors command\n%s", authorsUsage)
+	}
+
+	ctx := context.Background()
+	command, args := args[0], args[1:]
+	switch {
+	case command == "duplicates" && len(args) <= 1:
+		minSimilarity := models.DefaultDuplicateSimilarity
+		if len(args) == 1 {
+			var err error
+			minSimilarity, err = strconv.ParseFloat(args[0], 64)
+			if err != nil || !(minSimilarity > 0 && minSimilarity <= 1) {
+				return fmt.Errorf("duplicates: %q is not a similarity greater than 0 and at most 1", args[0])
+			}
+		}
+
+		duplicates, err := storage.FindDuplicateAuthors(ctx, minSimilarity, models.MaxDuplicateLimit)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SIMILARITY\tID\tAUTHOR\tID\tDUPLICATE")
+		for _, d := range duplicates {
+			fmt.Fprintf(w, "%.2f\t%d\t%s\t%d\t%s\n", d.Similarity, d.AuthorID, d.Author, d.DuplicateID, d.Duplicate)
+		}
+		return w.Flush()
+	case command == "merge" && len(args) >= 2:
+		ids := make([]int, len(args))
+		for i, arg := range args {
+			id, err := strconv.Atoi(arg)
+			if err != nil || id <= 0 {
+				return fmt.Errorf("merge: %q is not an author id", arg)
+			}
+			ids[i] = id
+		}
+
+		author, err := storage.MergeAuthors(ctx, ids[0], ids[1:])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("merged into %d %s, aliases: %s, quotes: %d\n",
+			author.ID, author.Name, strings.Join(author.Aliases, ", "), author.QuoteCount)
+		return nil
+	default:
+		return fmt.Errorf("unknown authors command %q\n%s", command, authorsUsage)
+	}
+}
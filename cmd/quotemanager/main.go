@@ -13,6 +13,8 @@ import (
 	"quotemanager/internal/handlers"
 	"quotemanager/internal/repositories"
 	"time"
+	// GET /quotes/daily accepts any IANA time zone, the runtime image ships none.
+	_ "time/tzdata"
 )
 
 func main() {
@@ -38,24 +40,74 @@ func main() {
 		log.Error("failed to connect to db", "error", err)
 		os.Exit(1)
 	}
-	if err := storage.Migrate(); err != nil {
-		log.Error("failed to migrate db", "error", err)
+	storage.RandomStrategy = repositories.RandomStrategy(cfg.RandomStrategy)
+	if !storage.RandomStrategy.Valid() {
+		log.Error("unknown random strategy", "strategy", cfg.RandomStrategy)
+		os.Exit(1)
+	}
+	storage.RotationTTL = cfg.RotationTTL
+
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(storage, flag.Args()[1:]); err != nil {
+			log.Error("failed to migrate db", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfg.AutoMigrate {
+		if err := storage.Migrate(); err != nil {
+			log.Error("failed to migrate db", "error", err)
+			os.Exit(1)
+		}
+	} else if err := storage.CheckSchema(); err != nil {
+		log.Error("refusing to start, database schema does not match", "error", err)
 		os.Exit(1)
 	}
 
 	log.Info("successfully connected to database")
 
+	if flag.Arg(0) == "authors" {
+		if err := runAuthors(storage, flag.Args()[1:]); err != nil {
+			log.Error("failed to run authors command", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	mux := http.NewServeMux()
 
 	mux.Handle("POST /quotes", handlers.AddQuoteHandler(log, storage))
 	mux.Handle("GET /quotes", handlers.GetQuotesHandler(log, storage))
 	mux.Handle("GET /quotes/random", handlers.GetRandomQuoteHandler(log, storage))
+	mux.Handle("GET /quotes/daily", handlers.GetDailyQuoteHandler(log, storage))
+	mux.Handle("GET /quotes/{quoteID}", handlers.GetQuoteHandler(log, storage))
+	mux.Handle("PUT /quotes/{quoteID}", handlers.UpdateQuoteHandler(log, storage))
+	mux.Handle("PATCH /quotes/{quoteID}", handlers.PatchQuoteHandler(log, storage))
 	mux.Handle("DELETE /quotes/{quoteID}", handlers.DeleteQuoteHandler(log, storage))
+	mux.Handle("POST /quotes/{quoteID}/restore", handlers.RestoreQuoteHandler(log, storage))
+	mux.Handle("GET /quotes/{quoteID}/history", handlers.GetQuoteHistoryHandler(log, storage))
+	mux.Handle("POST /quotes/{quoteID}/revert/{rev}", handlers.RevertQuoteHandler(log, storage))
+	mux.Handle("GET /trash", handlers.GetTrashHandler(log, storage))
+	mux.Handle("DELETE /trash", handlers.PurgeTrashHandler(log, storage))
+	mux.Handle("DELETE /trash/{quoteID}", handlers.PurgeQuoteHandler(log, storage))
+	mux.Handle("GET /authors/suggest", handlers.SuggestAuthorsHandler(log, storage))
+	mux.Handle("GET /authors/duplicates", handlers.FindDuplicateAuthorsHandler(log, storage))
+	mux.Handle("POST /authors", handlers.AddAuthorHandler(log, storage))
+	mux.Handle("GET /authors", handlers.GetAuthorsHandler(log, storage))
+	mux.Handle("GET /authors/{authorID}", handlers.GetAuthorHandler(log, storage))
+	mux.Handle("PUT /authors/{authorID}", handlers.UpdateAuthorHandler(log, storage))
+	mux.Handle("DELETE /authors/{authorID}", handlers.DeleteAuthorHandler(log, storage))
+	mux.Handle("GET /authors/{authorID}/quotes", handlers.GetAuthorQuotesHandler(log, storage))
+	mux.Handle("POST /authors/{authorID}/merge", handlers.MergeAuthorsHandler(log, storage))
+	mux.Handle("GET /tags", handlers.GetTagsHandler(log, storage))
+	mux.Handle("POST /tags/{tag}/rename", handlers.RenameTagHandler(log, storage))
+	mux.Handle("POST /tags/{tag}/merge", handlers.MergeTagsHandler(log, storage))
 
 	server := http.Server{
 		Addr:        cfg.HttpServerAddress,
 		ReadTimeout: cfg.HttpServerTimeout * time.Second,
-		Handler:     mux,
+		Handler:     handlers.RequestID(mux),
 	}
 
 	log.Info("server is listening on", "address", cfg.HttpServerAddress)
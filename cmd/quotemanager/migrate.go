@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"quotemanager/internal/repositories"
+)
+
+const migrateUsage = "usage: quotemanager [-config file] migrate up | down [N] | goto N | version | force N"
+
+// migrateCommand is a parsed migrate subcommand.
+type migrateCommand struct {
+	name string
+	// steps is the number of migrations down reverts.
+	steps int
+	// version is the target of goto and force, -1 for force means no migration.
+	version int
+}
+
+// parseMigrateArgs parses and validates the arguments of the migrate subcommand.
+func parseMigrateArgs(args []string) (migrateCommand, error) {
+	if len(args) == 0 {
+		return migrateCommand{}, fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+
+	command, args := migrateCommand{name: args[0]}, args[1:]
+	switch {
+	case command.name == "up" && len(args) == 0:
+	case command.name == "version" && len(args) == 0:
+	case command.name == "down" && len(args) <= 1:
+		command.steps = 1
+		if len(args) == 1 {
+			steps, err := strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				return migrateCommand{}, fmt.Errorf("down: %q is not a positive number of steps", args[0])
+			}
+			command.steps = steps
+		}
+	case command.name == "goto" && len(args) == 1:
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < 0 {
+			return migrateCommand{}, fmt.Errorf("goto: %q is not a version", args[0])
+		}
+		command.version = version
+	case command.name == "force" && len(args) == 1:
+		version, err := strconv.Atoi(args[0])
+		if err != nil || version < -1 {
+			return migrateCommand{}, fmt.Errorf("force: %q is not a version", args[0])
+		}
+		command.version = version
+	default:
+		return migrateCommand{}, fmt.Errorf("unknown migrate command %q\n%s", command.name, migrateUsage)
+	}
+	return command, nil
+}
+
+// runMigrate runs the migrate subcommand with its arguments.
+func runMigrate(storage *repositories.DB, args []string) error {
+	command, err := parseMigrateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	m, err := storage.Migrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch command.name {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down(command.steps)
+	case "goto":
+		err = m.Goto(uint(command.version))
+	case "force":
+		err = m.Force(command.version)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Printf("version %d (dirty)\n", version)
+	} else {
+		fmt.Printf("version %d\n", version)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMigrateArgs(t *testing.T) {
+	testTable := []struct {
+		name     string
+		args     []string
+		expected migrateCommand
+		wantErr  bool
+	}{
+		{name: "Up", args: []string{"up"}, expected: migrateCommand{name: "up"}},
+		{name: "Version", args: []string{"version"}, expected: migrateCommand{name: "version"}},
+		{name: "Down one step by default", args: []string{"down"}, expected: migrateCommand{name: "down", steps: 1}},
+		{name: "Down N steps", args: []string{"down", "3"}, expected: migrateCommand{name: "down", steps: 3}},
+		{name: "Goto", args: []string{"goto", "7"}, expected: migrateCommand{name: "goto", version: 7}},
+		{name: "Goto zero", args: []string{"goto", "0"}, expected: migrateCommand{name: "goto"}},
+		{name: "Force", args: []string{"force", "12"}, expected: migrateCommand{name: "force", version: 12}},
+		{name: "Force no migration", args: []string{"force", "-1"}, expected: migrateCommand{name: "force", version: -1}},
+		{name: "Missing command", args: nil, wantErr: true},
+		{name: "Unknown command", args: []string{"sideways"}, wantErr: true},
+		{name: "Up with an argument", args: []string{"up", "2"}, wantErr: true},
+		{name: "Version with an argument", args: []string{"version", "2"}, wantErr: true},
+		{name: "Down zero steps", args: []string{"down", "0"}, wantErr: true},
+		{name: "Down not a number", args: []string{"down", "all"}, wantErr: true},
+		{name: "Down with two arguments", args: []string{"down", "1", "2"}, wantErr: true},
+		{name: "Goto without version", args: []string{"goto"}, wantErr: true},
+		{name: "Goto negative", args: []string{"goto", "-1"}, wantErr: true},
+		{name: "Goto not a number", args: []string{"goto", "latest"}, wantErr: true},
+		{name: "Force below -1", args: []string{"force", "-2"}, wantErr: true},
+		{name: "Force not a number", args: []string{"force", "latest"}, wantErr: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			command, err := parseMigrateArgs(testCase.args)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expected, command)
+			}
+		})
+	}
+}
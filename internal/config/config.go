@@ -20,6 +20,9 @@ type Config struct {
 	HttpServerAddress string        `env:"HTTP_SERVER_ADDRESS" env-default:"localhost:8081"`
 	HttpServerTimeout time.Duration `env:"HTTP_SERVER_TIMEOUT" env-default:"5s"`
 	LogLevel          string        `env:"LOG_LEVEL" env-default:"DEBUG"`
+	RandomStrategy    string        `env:"RANDOM_STRATEGY" env-default:"probe"`
+	RotationTTL       time.Duration `env:"ROTATION_TTL" env-default:"24h"`
+	AutoMigrate       bool          `env:"AUTO_MIGRATE" env-default:"true"`
 	DBConfig          DBConfig
 }
 
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/internal/validation"
+	"quotemanager/pkg/errors"
+)
+
+// authorRequest is the body accepted when creating or replacing an author.
+type authorRequest struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases"`
+	BirthYear   *int     `json:"birth_year"`
+	DeathYear   *int     `json:"death_year"`
+	Bio         string   `json:"bio"`
+	Nationality string   `json:"nationality"`
+}
+
+// decodeAuthorRequest decodes and validates an authorRequest into a normalized author.
+func decodeAuthorRequest(w http.ResponseWriter, r *http.Request) (models.Author, error) {
+	var request authorRequest
+	if err := validation.DecodeJSON(w, r, &request); err != nil {
+		return models.Author{}, err
+	}
+
+	author := models.Author{
+		Name:        request.Name,
+		Aliases:     request.Aliases,
+		BirthYear:   request.BirthYear,
+		DeathYear:   request.DeathYear,
+		Bio:         request.Bio,
+		Nationality: request.Nationality,
+	}
+	if err := validation.Author(&author); err != nil {
+		return models.Author{}, err
+	}
+
+	return author, nil
+}
+
+func AddAuthorHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Adding author handler")
+		log.Info("Started adding author")
+
+		newAuthor, err := decodeAuthorRequest(w, r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		author, err := db.AddAuthor(r.Context(), newAuthor)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to add author: %w", err))
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/authors/%d", author.ID))
+		writeJSON(log, w, http.StatusCreated, author)
+		log.Info("Finished adding author", "author_id", author.ID)
+	}
+}
+
+// GetAuthorsHandler lists the authors by name, page by page with limit and offset.
+func GetAuthorsHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting authors handler")
+		log.Info("Started fetching authors")
+
+		query := r.URL.Query()
+
+		limit, err := intParam(query, "limit", models.DefaultPageLimit)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		if limit < 1 {
+			writeError(log, w, invalidParam("limit", "must be positive"))
+			return
+		}
+		limit = min(limit, models.MaxPageLimit)
+
+		offset, err := intParam(query, "offset", 0)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		if offset < 0 {
+			writeError(log, w, invalidParam("offset", "must not be negative"))
+			return
+		}
+
+		authors, err := db.GetAuthors(r.Context(), limit, offset)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to fetch authors: %w", err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, authors)
+		log.Info("Finished fetching authors", "count", len(authors))
+	}
+}
+
+func GetAuthorHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting author handler")
+		log.Info("Started getting author")
+
+		authorID, err := parseAuthorID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		author, err := db.GetAuthor(r.Context(), authorID)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to get author %d: %w", authorID, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, author)
+		log.Info("Finished getting author")
+	}
+}
+
+// UpdateAuthorHandler replaces an author. Renaming it keeps the old name as an alias.
+func UpdateAuthorHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Updating author handler")
+		log.Info("Started updating author")
+
+		authorID, err := parseAuthorID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		newAuthor, err := decodeAuthorRequest(w, r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		newAuthor.ID = authorID
+
+		author, err := db.UpdateAuthor(r.Context(), newAuthor)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to update author %d: %w", authorID, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, author)
+		log.Info("Finished updating author")
+	}
+}
+
+// DeleteAuthorHandler deletes an author without quotes, trashed ones included.
+func DeleteAuthorHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Deleting author handler")
+		log.Info("Started deleting author")
+
+		authorID, err := parseAuthorID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		if err := db.DeleteAuthor(r.Context(), authorID); err != nil {
+			writeError(log, w, fmt.Errorf("failed to delete author %d: %w", authorID, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, map[string]string{
+			"message": fmt.Sprintf("author with id %v was deleted successfully", authorID),
+		})
+		log.Info("Finished deleting author")
+	}
+}
+
+// GetAuthorQuotesHandler lists the quotes of an author with the filters and
+// pagination of GetQuotesHandler.
+func GetAuthorQuotesHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting author quotes handler")
+		log.Info("Started fetching author quotes")
+
+		authorID, err := parseAuthorID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		filters, err := parseQuoteFilter(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		filters.AuthorID = authorID
+
+		// An unknown author is a 404, not an empty page.
+		if _, err := db.GetAuthor(r.Context(), authorID); err != nil {
+			writeError(log, w, fmt.Errorf("failed to get author %d: %w", authorID, err))
+			return
+		}
+
+		page, err := db.GetQuotes(r.Context(), filters)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to fetch quotes of author %d: %w", authorID, err))
+			return
+		}
+
+		w.Header().Set("Link", pageLinks(r, page))
+		writeJSON(log, w, http.StatusOK, page)
+		log.Info("Finished fetching author quotes")
+	}
+}
+
+// FindDuplicateAuthorsHandler lists the pairs of authors with names at least
+// min_similarity alike, candidates for MergeAuthorsHandler.
+func FindDuplicateAuthorsHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Finding duplicate authors handler")
+		log.Info("Started finding duplicate authors")
+
+		query := r.URL.Query()
+
+		minSimilarity, err := floatParam(query, "min_similarity", models.DefaultDuplicateSimilarity)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		// Written this way round to reject NaN as well.
+		if !(minSimilarity > 0 && minSimilarity <= 1) {
+			writeError(log, w, invalidParam("min_similarity", "must be greater than 0 and at most 1"))
+			return
+		}
+
+		limit, err := intParam(query, "limit", models.DefaultDuplicateLimit)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		if limit < 1 {
+			writeError(log, w, invalidParam("limit", "must be positive"))
+			return
+		}
+		limit = min(limit, models.MaxDuplicateLimit)
+
+		duplicates, err := db.FindDuplicateAuthors(r.Context(), minSimilarity, limit)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to find duplicate authors: %w", err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, duplicates)
+		log.Info("Finished finding duplicate authors", "count", len(duplicates))
+	}
+}
+
+// MergeAuthorsHandler merges the authors listed in the "authors" member of
+// the body into the author in the path, see repositories.DB.MergeAuthors.
+func MergeAuthorsHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Merging authors handler")
+		log.Info("Started merging authors")
+
+		authorID, err := parseAuthorID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		var request struct {
+			Authors []int `json:"authors"`
+		}
+		if err := validation.DecodeJSON(w, r, &request); err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		var errs validation.Errors
+		switch {
+		case len(request.Authors) == 0:
+			errs.Add("authors", "is required")
+		case len(request.Authors) > models.MaxMergedAuthors:
+			errs.Add("authors", fmt.Sprintf("must list at most %d authors, got %d", models.MaxMergedAuthors, len(request.Authors)))
+		}
+		for i, id := range request.Authors {
+			if id <= 0 {
+				errs.Add(fmt.Sprintf("authors[%d]", i), "must be a positive integer")
+			} else if id == authorID {
+				errs.Add(fmt.Sprintf("authors[%d]", i), "must differ from the author merged into")
+			}
+		}
+		if err := errs.OrNil(); err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		author, err := db.MergeAuthors(r.Context(), authorID, request.Authors)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to merge authors into %d: %w", authorID, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, author)
+		log.Info("Finished merging authors", "into", authorID, "merged", len(request.Authors))
+	}
+}
+
+// parseAuthorID reads the authorID path value and makes sure it is a positive integer.
+func parseAuthorID(r *http.Request) (int, error) {
+	raw := r.PathValue("authorID")
+	authorID, err := strconv.Atoi(raw)
+	if err != nil || authorID <= 0 {
+		return 0, errors.New(errors.CodeInvalidArgument, fmt.Sprintf("invalid author id %q: must be a positive integer", raw))
+	}
+	return authorID, nil
+}
+
+func SuggestAuthorsHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Suggesting authors handler")
+		log.Info("Started suggesting authors")
+
+		query := r.URL.Query()
+
+		prefix := strings.TrimSpace(query.Get("prefix"))
+		if prefix == "" {
+			writeError(log, w, invalidParam("prefix", "is required"))
+			return
+		}
+
+		limit, err := intParam(query, "limit", models.DefaultSuggestLimit)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		if limit < 1 {
+			writeError(log, w, invalidParam("limit", "must be positive"))
+			return
+		}
+		limit = min(limit, models.MaxSuggestLimit)
+
+		suggestions, err := db.SuggestAuthors(r.Context(), prefix, limit)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to suggest authors: %w", err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, suggestions)
+		log.Info("Finished suggesting authors")
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+)
+
+func GetDailyQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting daily quote handler")
+		log.Info("Started getting daily quote")
+
+		tz := r.URL.Query().Get("tz")
+		if tz == "" {
+			tz = models.DefaultDailyTimezone
+		}
+		// "Local" would make the answer depend on the server configuration.
+		loc, err := time.LoadLocation(tz)
+		if err != nil || tz == "Local" {
+			writeError(log, w, invalidParam("tz", "must be an IANA time zone such as Europe/Moscow"))
+			return
+		}
+
+		daily, err := db.GetDailyQuote(r.Context(), loc.String(), time.Now().In(loc))
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to get daily quote: %w", err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, daily)
+		log.Info("Finished getting daily quote", "quote_id", daily.ID, "date", daily.Date)
+	}
+}
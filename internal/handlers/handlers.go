@@ -2,90 +2,188 @@ package handlers
 
 import (
 	"encoding/json"
-	stdErrors "errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
 
 	"quotemanager/internal/models"
 	"quotemanager/internal/repositories"
+	"quotemanager/internal/validation"
 	"quotemanager/pkg/errors"
 )
 
+// quoteRequest is the body accepted when creating or replacing a quote.
+type quoteRequest struct {
+	Author            string                   `json:"author"`
+	Quote             string                   `json:"quote"`
+	Weight            *float64                 `json:"weight"`
+	Tags              []string                 `json:"tags"`
+	Source            models.Source            `json:"source"`
+	AttributionStatus models.AttributionStatus `json:"attribution_status"`
+}
+
+// decodeQuoteRequest decodes and validates a quoteRequest into a normalized quote.
+func decodeQuoteRequest(w http.ResponseWriter, r *http.Request) (models.Quote, error) {
+	var request quoteRequest
+	if err := validation.DecodeJSON(w, r, &request); err != nil {
+		return models.Quote{}, err
+	}
+
+	quote := models.Quote{
+		Author:            request.Author,
+		Quote:             request.Quote,
+		Weight:            models.DefaultQuoteWeight,
+		Tags:              request.Tags,
+		Source:            request.Source,
+		AttributionStatus: request.AttributionStatus,
+	}
+	if request.Weight != nil {
+		quote.Weight = *request.Weight
+	}
+	if err := validation.Quote(&quote); err != nil {
+		return models.Quote{}, err
+	}
+
+	return quote, nil
+}
+
 func AddQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Debug("adding quote handler")
 		log.Info("start adding quote")
-		var request struct {
-			Author string `json:"author"`
-			Quote  string `json:"quote"`
+
+		newQuote, err := decodeQuoteRequest(w, r)
+		if err != nil {
+			writeError(log, w, err)
+			return
 		}
 
-		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			log.Error("failed to decode request body", "error", err)
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+		quote, err := db.AddQuote(r.Context(), newQuote)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to add quote: %w", err))
 			return
 		}
 
-		newQuote := models.Quote{
-			Author: request.Author,
-			Quote:  request.Quote,
+		w.Header().Set("Location", fmt.Sprintf("/quotes/%d", quote.ID))
+		writeJSON(log, w, http.StatusCreated, quote)
+
+		log.Info("Finished adding quote", "quote_id", quote.ID)
+	}
+}
+
+func GetQuotesHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting quote data handler")
+		log.Info("Started fetching quote")
+
+		filters, err := parseQuoteFilter(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
 		}
 
-		if err := db.AddQuote(r.Context(), newQuote); err != nil {
-			log.Error("failed to add quote", "error", err)
-			http.Error(w, "Failed to add quote", http.StatusInternalServerError)
+		page, err := db.GetQuotes(r.Context(), filters)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to fetch quotes: %w", err))
 			return
 		}
 
-		w.WriteHeader(http.StatusCreated)
-		_, err := w.Write([]byte("Quote was added successfully\n"))
+		w.Header().Set("Link", pageLinks(r, page))
+		writeJSON(log, w, http.StatusOK, page)
+		log.Info("Finished fetching quotes")
+	}
+}
+
+func GetQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting quote handler")
+		log.Info("Started getting quote")
+
+		quoteID, err := parseQuoteID(r)
 		if err != nil {
-			log.Error("error writing", "error", err)
+			writeError(log, w, err)
+			return
+		}
+
+		quote, err := db.GetQuote(r.Context(), quoteID)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to get quote %d: %w", quoteID, err))
+			return
 		}
 
-		log.Info("Finished adding quote")
+		writeJSON(log, w, http.StatusOK, quote)
+		log.Info("Finished getting quote")
 	}
 }
 
-func GetQuotesHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+func UpdateQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Debug("Getting quote data handler")
-		log.Info("Started fetching quote")
+		log.Debug("Updating quote handler")
+		log.Info("Started updating quote")
 
-		filters := models.QuoteFilter{
-			Author: r.URL.Query().Get("author"),
+		quoteID, err := parseQuoteID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
 		}
 
-		quotes, err := db.GetQuotes(r.Context(), filters)
+		newQuote, err := decodeQuoteRequest(w, r)
 		if err != nil {
-			log.Error("Failed to fetch quotes", "error", err)
-			http.Error(w, "Failed to fetch quotes", http.StatusInternalServerError)
+			writeError(log, w, err)
 			return
 		}
+		newQuote.ID = quoteID
 
-		if len(quotes) == 0 {
-			w.Header().Set("Content-Type", "application/json")
-			_, err := w.Write([]byte("[]\n"))
-			if err != nil {
-				log.Error("error writing empty response", "error", err)
-			}
-		} else {
-			w.Header().Set("Content-Type", "application/json")
+		quote, err := db.UpdateQuote(r.Context(), newQuote)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to update quote %d: %w", quoteID, err))
+			return
+		}
 
-			jsonData, err := json.MarshalIndent(quotes, "", "  ")
-			if err != nil {
-				log.Error("failed to encode quotes to JSON", "error", err)
-				http.Error(w, "Failed to encode quotes", http.StatusInternalServerError)
+		writeJSON(log, w, http.StatusOK, quote)
+		log.Info("Finished updating quote")
+	}
+}
+
+func PatchQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Patching quote handler")
+		log.Info("Started patching quote")
+
+		quoteID, err := parseQuoteID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		if contentType != "" {
+			mediaType, _, err := mime.ParseMediaType(contentType)
+			if err != nil || (mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+				writeError(log, w, errors.New(errors.CodeUnsupportedMediaType,
+					fmt.Sprintf("content type %q is not supported, use application/merge-patch+json", contentType)))
 				return
 			}
+		}
 
-			_, err = w.Write(jsonData)
-			if err != nil {
-				log.Error("error writing", "error", err)
-			}
+		patch, err := decodeQuotePatch(w, r)
+		if err != nil {
+			writeError(log, w, err)
+			return
 		}
-		log.Info("Finished fetching quotes")
+
+		quote, err := db.PatchQuote(r.Context(), quoteID, patch)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to patch quote %d: %w", quoteID, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, quote)
+		log.Info("Finished patching quote")
 	}
 }
 
@@ -93,60 +191,215 @@ func DeleteQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.Hand
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Debug("Deleting quote handler")
 		log.Info("Started deleting quote")
-		quoteID := r.PathValue("quoteID")
+		quoteID, err := parseQuoteID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
 
 		if err := db.DeleteQuote(r.Context(), quoteID); err != nil {
-			if stdErrors.Is(err, errors.ErrQuoteNotFound) {
-				log.Warn("The quote to delete is not found", "error", err)
-				http.Error(w, "The quote to delete is not found", http.StatusNotFound)
-			} else {
-				log.Error("failed to delete quote", "error", err)
-				http.Error(w, "Failed to delete quote", http.StatusInternalServerError)
-			}
+			writeError(log, w, fmt.Errorf("failed to delete quote %d: %w", quoteID, err))
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
-		outstr := fmt.Sprintf("quote with id %v was deleted successfully\n", quoteID)
-		_, err := w.Write([]byte(outstr))
-		if err != nil {
-			log.Error("error writing", "error", err)
-		}
+		writeJSON(log, w, http.StatusOK, map[string]string{
+			"message": fmt.Sprintf("quote with id %v was deleted successfully", quoteID),
+		})
 
 		log.Info("Finished deleting quote")
 	}
 }
 
+// GetRandomQuoteHandler accepts the filters of GetQuotesHandler, but not its
+// pagination and sort parameters. Without the count parameter it responds
+// with a single quote, with it with a list of up to count distinct quotes.
 func GetRandomQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Debug("Started getting random quote handler")
 		log.Info("Started getting random quote")
 
-		quote, err := db.GetRandomQuote(r.Context())
+		filters, err := parseRandomFilter(r)
 		if err != nil {
-			if stdErrors.Is(err, errors.ErrQuoteNotFound) {
-				log.Warn("random quote not found", "error", err)
-				http.Error(w, "Random quote not found", http.StatusNotFound)
-			} else {
-				log.Error("failed to get random quote", "error", err)
-				http.Error(w, "Failed to get random quote", http.StatusInternalServerError)
-			}
+			writeError(log, w, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-
-		jsonData, err := json.MarshalIndent(quote, "", "  ")
+		query := r.URL.Query()
+		count, err := intParam(query, "count", 1)
 		if err != nil {
-			log.Error("failed to encode quotes to JSON", "error", err)
-			http.Error(w, "Failed to encode quotes", http.StatusInternalServerError)
+			writeError(log, w, err)
+			return
+		}
+		if count < 1 {
+			writeError(log, w, invalidParam("count", "must be positive"))
+			return
+		}
+		count = min(count, models.MaxRandomCount)
+
+		seed := query.Get("seed")
+		if query.Has("seed") && !validSeed(seed) {
+			writeError(log, w, invalidParam("seed", fmt.Sprintf("must be 1 to %d printable ASCII characters", models.MaxSeedLength)))
+			return
+		}
+		if seed == "" {
+			seed = newSeed()
+		}
+
+		mode := models.RandomMode(query.Get("mode"))
+		if mode != "" && !models.RandomModes[mode] {
+			writeError(log, w, invalidParam("mode", "must be one of uniform, weighted"))
 			return
 		}
 
-		_, err = w.Write(jsonData)
+		client := query.Get("client")
+		if query.Has("client") && !validClient(client) {
+			writeError(log, w, invalidParam("client", fmt.Sprintf("must be 1 to %d printable ASCII characters", models.MaxClientLength)))
+			return
+		}
+
+		quotes, err := db.GetRandomQuotes(r.Context(), models.RandomOptions{
+			Filter: filters,
+			Count:  count,
+			Mode:   mode,
+			Seed:   seed,
+			Client: client,
+		})
 		if err != nil {
-			log.Error("error writing", "error", err)
+			writeError(log, w, fmt.Errorf("failed to get random quote: %w", err))
+			return
+		}
+
+		// Echoing the seed lets clients reproduce any random answer.
+		w.Header().Set(randomSeedHeader, seed)
+		if query.Has("count") {
+			writeJSON(log, w, http.StatusOK, quotes)
+		} else {
+			writeJSON(log, w, http.StatusOK, quotes[0])
+		}
+		log.Info("Finished getting random quote", "count", len(quotes))
+	}
+}
+
+// parseQuoteID reads the quoteID path value and makes sure it is a positive integer.
+func parseQuoteID(r *http.Request) (int, error) {
+	raw := r.PathValue("quoteID")
+	quoteID, err := strconv.Atoi(raw)
+	if err != nil || quoteID <= 0 {
+		return 0, errors.New(errors.CodeInvalidArgument, fmt.Sprintf("invalid quote id %q: must be a positive integer", raw))
+	}
+	return quoteID, nil
+}
+
+// decodeQuotePatch reads an RFC 7396 merge patch for a quote. Both author and quote
+// are mandatory, so an explicit null (which would remove the member) is rejected.
+// Tags are replaced as a whole and null removes all of them, the source is
+// merged member by member, see decodeSourcePatch. The attribution status can
+// be changed but not removed.
+func decodeQuotePatch(w http.ResponseWriter, r *http.Request) (models.QuotePatch, error) {
+	var members map[string]json.RawMessage
+	if err := validation.DecodeJSON(w, r, &members); err != nil {
+		return models.QuotePatch{}, err
+	}
+	if members == nil {
+		return models.QuotePatch{}, fmt.Errorf("%w: merge patch must be a JSON object", validation.ErrMalformedBody)
+	}
+
+	var (
+		patch models.QuotePatch
+		errs  validation.Errors
+	)
+	for _, name := range slices.Sorted(maps.Keys(members)) {
+		raw := members[name]
+
+		switch name {
+		case "author", "quote", "weight", "tags", "source", "attribution_status":
+		default:
+			errs.Add(name, "unknown field")
+			continue
+		}
+
+		if name == "tags" {
+			var value []string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				errs.Add(name, "must be an array of strings")
+				continue
+			}
+			patch.Tags = &value
+			continue
+		}
+
+		if name == "source" {
+			source, ok := decodeSourcePatch(&errs, raw)
+			if ok {
+				patch.Source = source
+			}
+			continue
+		}
+
+		if string(raw) == "null" {
+			errs.Add(name, "cannot be removed")
+			continue
+		}
+
+		if name == "weight" {
+			var value float64
+			if err := json.Unmarshal(raw, &value); err != nil {
+				errs.Add(name, "must be of type number")
+				continue
+			}
+			patch.Weight = &value
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			errs.Add(name, "must be of type string")
+			continue
+		}
+		switch name {
+		case "author":
+			patch.Author = &value
+		case "quote":
+			patch.Quote = &value
+		default:
+			status := models.AttributionStatus(value)
+			patch.AttributionStatus = &status
+		}
+	}
+	if err := errs.OrNil(); err != nil {
+		return models.QuotePatch{}, err
+	}
+
+	if err := validation.QuotePatch(&patch); err != nil {
+		return models.QuotePatch{}, err
+	}
+
+	return patch, nil
+}
+
+// decodeSourcePatch decodes the source member of a quote merge patch. The
+// members given are merged into the source as in RFC 7396, null removes a
+// member and a null source removes all of them.
+func decodeSourcePatch(errs *validation.Errors, raw json.RawMessage) (*models.SourcePatch, bool) {
+	var (
+		members map[string]json.RawMessage
+		value   models.Source
+	)
+	if json.Unmarshal(raw, &members) != nil || json.Unmarshal(raw, &value) != nil {
+		errs.Add("source", "must be an object with title, year, page, url, translator and original_text")
+		return nil, false
+	}
+	if members == nil {
+		return &models.SourcePatch{Members: models.SourceMembers}, true
+	}
+
+	names := slices.Sorted(maps.Keys(members))
+	ok := true
+	for _, name := range names {
+		if !slices.Contains(models.SourceMembers, name) {
+			errs.Add("source."+name, "unknown field")
+			ok = false
 		}
-		log.Info("Finished getting random quote")
 	}
+	return &models.SourcePatch{Members: names, Source: value}, ok
 }
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/internal/validation"
+	"quotemanager/pkg/errors"
+)
+
+func TestDecodeQuotePatch(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	weight := 2.5
+	verified := models.AttributionVerified
+	year := 65
+
+	testTable := []struct {
+		name       string
+		body       string
+		expected   models.QuotePatch
+		wantFields []errors.FieldError
+		wantErr    error
+	}{
+		{
+			name:     "OK - Empty patch",
+			body:     `{}`,
+			expected: models.QuotePatch{},
+		},
+		{
+			name:     "OK - Author normalized",
+			body:     `{"author":"  Seneca "}`,
+			expected: models.QuotePatch{Author: ptr("Seneca")},
+		},
+		{
+			name: "OK - Every field",
+			body: `{"author":"Seneca","quote":"Luck is what happens when preparation meets opportunity.","weight":2.5,` +
+				`"tags":["Stoicism"],"source":{"title":"Moral Letters","year":65},"attribution_status":"verified"}`,
+			expected: models.QuotePatch{
+				Author:            ptr("Seneca"),
+				Quote:             ptr("Luck is what happens when preparation meets opportunity."),
+				Weight:            &weight,
+				Tags:              &[]string{"stoicism"},
+				Source:            &models.SourcePatch{Members: []string{"title", "year"}, Source: models.Source{Title: "Moral Letters", Year: &year}},
+				AttributionStatus: &verified,
+			},
+		},
+		{
+			name:     "OK - Null tags and source remove them",
+			body:     `{"tags":null,"source":null}`,
+			expected: models.QuotePatch{Tags: &[]string{}, Source: &models.SourcePatch{Members: models.SourceMembers}},
+		},
+		{
+			name:     "OK - Partial source",
+			body:     `{"source":{"page":" 12 ","year":null}}`,
+			expected: models.QuotePatch{Source: &models.SourcePatch{Members: []string{"page", "year"}, Source: models.Source{Page: "12"}}},
+		},
+		{
+			name: "Null mandatory fields",
+			body: `{"attribution_status":null,"author":null,"quote":null,"weight":null}`,
+			wantFields: []errors.FieldError{
+				{Field: "attribution_status", Message: "cannot be removed"},
+				{Field: "author", Message: "cannot be removed"},
+				{Field: "quote", Message: "cannot be removed"},
+				{Field: "weight", Message: "cannot be removed"},
+			},
+		},
+		{
+			name: "Mistyped fields",
+			body: `{"attribution_status":1,"author":1,"quote":true,"source":"x","tags":"x","weight":"x"}`,
+			wantFields: []errors.FieldError{
+				{Field: "attribution_status", Message: "must be of type string"},
+				{Field: "author", Message: "must be of type string"},
+				{Field: "quote", Message: "must be of type string"},
+				{Field: "source", Message: "must be an object with title, year, page, url, translator and original_text"},
+				{Field: "tags", Message: "must be an array of strings"},
+				{Field: "weight", Message: "must be of type number"},
+			},
+		},
+		{
+			name:       "Unknown source field",
+			body:       `{"source":{"isbn":"123"}}`,
+			wantFields: []errors.FieldError{{Field: "source.isbn", Message: "unknown field"}},
+		},
+		{
+			name:       "Unknown field",
+			body:       `{"author":"Seneca","id":7}`,
+			wantFields: []errors.FieldError{{Field: "id", Message: "unknown field"}},
+		},
+		{
+			name: "Invalid values",
+			body: `{"attribution_status":"rumour","weight":-1}`,
+			wantFields: []errors.FieldError{
+				{Field: "weight", Message: "must be between 0 and 1000"},
+				{Field: "attribution_status", Message: "must be one of verified, disputed, misattributed, unknown"},
+			},
+		},
+		{
+			name:    "Null body",
+			body:    `null`,
+			wantErr: validation.ErrMalformedBody,
+		},
+		{
+			name:    "Not an object",
+			body:    `["author"]`,
+			wantErr: validation.ErrMalformedBody,
+		},
+		{
+			name:    "Trailing data",
+			body:    `{} {}`,
+			wantErr: validation.ErrMalformedBody,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/quotes/1", strings.NewReader(testCase.body))
+
+			patch, err := decodeQuotePatch(httptest.NewRecorder(), r)
+
+			switch {
+			case testCase.wantErr != nil:
+				assert.ErrorIs(t, err, testCase.wantErr)
+			case testCase.wantFields != nil:
+				appErr, ok := errors.As(err)
+				require.True(t, ok, "expected an application error, got %v", err)
+				assert.Equal(t, errors.CodeValidation, appErr.Code)
+				assert.Equal(t, testCase.wantFields, appErr.Fields)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expected, patch)
+			}
+		})
+	}
+}
+
+// stubDB answers the quote calls of the handlers under test with fixed
+// results, calling any other method of DBInterface panics.
+type stubDB struct {
+	repositories.DBInterface
+
+	quote  models.Quote
+	page   models.QuotePage
+	quotes []models.Quote
+	err    error
+
+	// randomOpts records the options of the last GetRandomQuotes call.
+	randomOpts models.RandomOptions
+}
+
+func (db *stubDB) AddQuote(_ context.Context, quote models.Quote) (models.Quote, error) {
+	if db.err != nil {
+		return models.Quote{}, db.err
+	}
+	quote.ID = db.quote.ID
+	return quote, nil
+}
+
+func (db *stubDB) GetQuotes(context.Context, models.QuoteFilter) (models.QuotePage, error) {
+	return db.page, db.err
+}
+
+func (db *stubDB) GetRandomQuotes(_ context.Context, opts models.RandomOptions) ([]models.Quote, error) {
+	db.randomOpts = opts
+	return db.quotes, db.err
+}
+
+func TestAddQuoteHandler(t *testing.T) {
+	testTable := []struct {
+		name         string
+		body         string
+		db           *stubDB
+		wantStatus   int
+		wantLocation string
+		wantType     string
+	}{
+		{
+			name:         "OK - Created",
+			body:         `{"author":"Seneca","quote":"Luck is what happens when preparation meets opportunity."}`,
+			db:           &stubDB{quote: models.Quote{ID: 7}},
+			wantStatus:   http.StatusCreated,
+			wantLocation: "/quotes/7",
+		},
+		{
+			name:       "Missing quote",
+			body:       `{"author":"Seneca"}`,
+			db:         &stubDB{},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantType:   "/problems/validation",
+		},
+		{
+			name:       "DB error",
+			body:       `{"author":"Seneca","quote":"Luck is what happens when preparation meets opportunity."}`,
+			db:         &stubDB{err: errors.ErrQuery},
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "/problems/internal",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/quotes", strings.NewReader(testCase.body))
+			w := httptest.NewRecorder()
+
+			AddQuoteHandler(newTestLogger(), testCase.db).ServeHTTP(w, r)
+
+			assert.Equal(t, testCase.wantStatus, w.Code)
+			assert.Equal(t, testCase.wantLocation, w.Header().Get("Location"))
+			if testCase.wantType != "" {
+				var p problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+				assert.Equal(t, testCase.wantType, p.Type)
+				return
+			}
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			var quote models.Quote
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &quote))
+			assert.Equal(t, 7, quote.ID)
+			assert.Equal(t, "Seneca", quote.Author)
+		})
+	}
+}
+
+func TestGetQuotesHandler_Link(t *testing.T) {
+	testTable := []struct {
+		name     string
+		target   string
+		page     models.QuotePage
+		wantLink string
+	}{
+		{
+			name:     "First page only",
+			target:   "/quotes?author=Seneca",
+			page:     models.QuotePage{Quotes: []models.Quote{}},
+			wantLink: `</quotes?author=Seneca>; rel="first"`,
+		},
+		{
+			name:     "Next and previous pages",
+			target:   "/quotes?limit=2&cursor=b",
+			page:     models.QuotePage{Quotes: []models.Quote{}, NextCursor: "c", PrevCursor: "a"},
+			wantLink: `</quotes?limit=2>; rel="first", </quotes?cursor=c&limit=2>; rel="next", </quotes?cursor=a&limit=2>; rel="prev"`,
+		},
+		{
+			name:     "Offset is replaced by the cursor",
+			target:   "/quotes?offset=40&sort=-created_at",
+			page:     models.QuotePage{Quotes: []models.Quote{}, NextCursor: "c"},
+			wantLink: `</quotes?sort=-created_at>; rel="first", </quotes?cursor=c&sort=-created_at>; rel="next"`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", testCase.target, nil)
+			w := httptest.NewRecorder()
+
+			GetQuotesHandler(newTestLogger(), &stubDB{page: testCase.page}).ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, testCase.wantLink, w.Header().Get("Link"))
+		})
+	}
+}
+
+func TestGetRandomQuoteHandler_Seed(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+	testTable := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantSeed   string
+	}{
+		{name: "OK - Given seed", target: "/quotes/random?seed=lobby-42", wantStatus: http.StatusOK, wantSeed: "lobby-42"},
+		{name: "OK - Generated seed", target: "/quotes/random", wantStatus: http.StatusOK},
+		{name: "Invalid seed", target: "/quotes/random?seed=" + strings.Repeat("s", models.MaxSeedLength+1), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := &stubDB{quotes: []models.Quote{{ID: 3, Author: "Seneca", Quote: "Quote3"}}}
+			r := httptest.NewRequest("GET", testCase.target, nil)
+			w := httptest.NewRecorder()
+
+			GetRandomQuoteHandler(newTestLogger(), db).ServeHTTP(w, r)
+
+			assert.Equal(t, testCase.wantStatus, w.Code)
+			seed := w.Header().Get(randomSeedHeader)
+			switch {
+			case testCase.wantStatus != http.StatusOK:
+				assert.Empty(t, seed, "rejected requests were not drawn with a seed")
+			case testCase.wantSeed != "":
+				assert.Equal(t, testCase.wantSeed, seed)
+			default:
+				assert.Regexp(t, generated, seed)
+			}
+			assert.Equal(t, seed, db.randomOpts.Seed, "the echoed seed must be the one the quotes were drawn with")
+		})
+	}
+}
+
+func TestGetRandomQuoteHandler_ListingParams(t *testing.T) {
+	testTable := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantDetail string
+	}{
+		{name: "OK - Filters", target: "/quotes/random?author=Seneca&max_length=120", wantStatus: http.StatusOK},
+		{name: "Limit", target: "/quotes/random?limit=5", wantStatus: http.StatusBadRequest, wantDetail: `query parameter "limit": is not supported by random quotes`},
+		{name: "Offset", target: "/quotes/random?offset=20", wantStatus: http.StatusBadRequest, wantDetail: `query parameter "offset": is not supported by random quotes`},
+		{name: "Cursor", target: "/quotes/random?cursor=abc", wantStatus: http.StatusBadRequest, wantDetail: `query parameter "cursor": is not supported by random quotes`},
+		{name: "Include total", target: "/quotes/random?include_total=true", wantStatus: http.StatusBadRequest, wantDetail: `query parameter "include_total": is not supported by random quotes`},
+		{name: "Sort", target: "/quotes/random?sort=-created_at", wantStatus: http.StatusBadRequest, wantDetail: `query parameter "sort": is not supported by random quotes`},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			db := &stubDB{quotes: []models.Quote{{ID: 3, Author: "Seneca", Quote: "Quote3"}}}
+			r := httptest.NewRequest("GET", testCase.target, nil)
+			w := httptest.NewRecorder()
+
+			GetRandomQuoteHandler(newTestLogger(), db).ServeHTTP(w, r)
+
+			assert.Equal(t, testCase.wantStatus, w.Code)
+			if testCase.wantDetail != "" {
+				var p problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+				assert.Equal(t, testCase.wantDetail, p.Detail)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/pkg/errors"
+)
+
+func TestRequestID(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	testTable := []struct {
+		name     string
+		incoming string
+		// keep reports whether the incoming ID is echoed, otherwise a new one is generated.
+		keep bool
+	}{
+		{name: "Incoming ID is kept", incoming: "edge-7f3a", keep: true},
+		{name: "Missing ID is generated", incoming: ""},
+		{name: "ID with spaces is replaced", incoming: "abc def"},
+		{name: "Too long ID is replaced", incoming: strings.Repeat("a", 129)},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			var seen string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = r.Header.Get(requestIDHeader)
+				writeError(newTestLogger(), w, errors.ErrQuoteNotFound)
+			}))
+
+			r := httptest.NewRequest("GET", "/quotes/7", nil)
+			if testCase.incoming != "" {
+				r.Header.Set(requestIDHeader, testCase.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			echoed := w.Header().Get(requestIDHeader)
+			if testCase.keep {
+				assert.Equal(t, testCase.incoming, echoed)
+			} else {
+				assert.Regexp(t, generated, echoed)
+			}
+			assert.Equal(t, echoed, seen, "the handler must see the echoed ID")
+
+			var p problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, echoed, p.RequestID, "problem responses must carry the echoed ID")
+		})
+	}
+}
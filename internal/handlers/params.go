@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/validation"
+	"quotemanager/pkg/errors"
+)
+
+// listingParams are the pagination and ordering query parameters of a quote
+// listing, on top of the filters of parseFilterParams.
+var listingParams = []string{"limit", "offset", "cursor", "include_total", "sort"}
+
+// parseQuoteFilter reads the filtering and pagination query parameters of a quote listing.
+func parseQuoteFilter(r *http.Request) (models.QuoteFilter, error) {
+	query := r.URL.Query()
+
+	filters, err := parseFilterParams(query)
+	if err != nil {
+		return models.QuoteFilter{}, err
+	}
+	filters.Cursor = query.Get("cursor")
+
+	if filters.Limit, err = intParam(query, "limit", models.DefaultPageLimit); err != nil {
+		return models.QuoteFilter{}, err
+	}
+	if filters.Limit < 1 {
+		return models.QuoteFilter{}, invalidParam("limit", "must be positive")
+	}
+	// Larger pages are not an error, the client just gets the maximum.
+	filters.Limit = min(filters.Limit, models.MaxPageLimit)
+
+	if filters.Offset, err = intParam(query, "offset", 0); err != nil {
+		return models.QuoteFilter{}, err
+	}
+	if filters.Offset < 0 {
+		return models.QuoteFilter{}, invalidParam("offset", "must not be negative")
+	}
+	if filters.Offset > 0 && filters.Cursor != "" {
+		return models.QuoteFilter{}, invalidParam("offset", "cannot be combined with cursor")
+	}
+
+	if filters.WithTotal, err = boolParam(query, "include_total"); err != nil {
+		return models.QuoteFilter{}, err
+	}
+
+	if filters.Sort, err = models.ParseSort(query.Get("sort")); err != nil {
+		return models.QuoteFilter{}, invalidParam("sort", err.Error())
+	}
+
+	return filters, nil
+}
+
+// parseRandomFilter reads the filters of a random quote request. Random
+// quotes are neither paginated nor ordered, so the listing parameters are
+// rejected rather than silently ignored.
+func parseRandomFilter(r *http.Request) (models.QuoteFilter, error) {
+	query := r.URL.Query()
+	for _, name := range listingParams {
+		if query.Has(name) {
+			return models.QuoteFilter{}, invalidParam(name, "is not supported by random quotes")
+		}
+	}
+	return parseFilterParams(query)
+}
+
+// parseFilterParams reads the query parameters filtering quotes.
+func parseFilterParams(query url.Values) (models.QuoteFilter, error) {
+	filters := models.QuoteFilter{
+		Author:      query.Get("author"),
+		AuthorMatch: models.AuthorMatch(query.Get("author_match")),
+		Search:      strings.TrimSpace(query.Get("q")),
+		Language:    query.Get("lang"),
+	}
+
+	if filters.AuthorMatch != "" && !models.AuthorMatches[filters.AuthorMatch] {
+		return models.QuoteFilter{}, invalidParam("author_match", "must be one of exact, insensitive, prefix, substring, fuzzy")
+	}
+
+	if filters.Language != "" && !models.SearchLanguages[filters.Language] {
+		return models.QuoteFilter{}, invalidParam("lang", "must be one of english, russian")
+	}
+
+	var err error
+	if filters.Tags, err = tagsParam(query, "tags"); err != nil {
+		return models.QuoteFilter{}, err
+	}
+	filters.TagMatch = models.TagMatch(query.Get("tag_match"))
+	if filters.TagMatch != "" && !models.TagMatches[filters.TagMatch] {
+		return models.QuoteFilter{}, invalidParam("tag_match", "must be one of any, all")
+	}
+	if filters.AttributionStatuses, err = attributionStatusesParam(query, "attribution_status"); err != nil {
+		return models.QuoteFilter{}, err
+	}
+
+	if filters.MinLength, err = intParam(query, "min_length", 0); err != nil {
+		return models.QuoteFilter{}, err
+	}
+	if filters.MaxLength, err = intParam(query, "max_length", 0); err != nil {
+		return models.QuoteFilter{}, err
+	}
+	if filters.MinLength < 0 {
+		return models.QuoteFilter{}, invalidParam("min_length", "must not be negative")
+	}
+	if filters.MaxLength < 0 {
+		return models.QuoteFilter{}, invalidParam("max_length", "must not be negative")
+	}
+	if filters.MaxLength > 0 && filters.MinLength > filters.MaxLength {
+		return models.QuoteFilter{}, invalidParam("min_length", "must not be greater than max_length")
+	}
+
+	if filters.CreatedAfter, err = timeParam(query, "created_after"); err != nil {
+		return models.QuoteFilter{}, err
+	}
+	if filters.CreatedBefore, err = timeParam(query, "created_before"); err != nil {
+		return models.QuoteFilter{}, err
+	}
+
+	return filters, nil
+}
+
+func intParam(query url.Values, name string, def int) (int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, invalidParam(name, "must be an integer")
+	}
+	return v, nil
+}
+
+func boolParam(query url.Values, name string) (bool, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, invalidParam(name, "must be a boolean")
+	}
+	return v, nil
+}
+
+func floatParam(query url.Values, name string, def float64) (float64, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, invalidParam(name, "must be a number")
+	}
+	return v, nil
+}
+
+// tagsParam parses a comma separated list of tags into distinct slugs.
+func tagsParam(query url.Values, name string) ([]string, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		slug := validation.Tag(tag)
+		if problem := validation.CheckTag(slug); problem != "" {
+			return nil, invalidParam(name, fmt.Sprintf("tag %q %s", tag, problem))
+		}
+		if !slices.Contains(tags, slug) {
+			tags = append(tags, slug)
+		}
+	}
+	if len(tags) > validation.MaxQuoteTags {
+		return nil, invalidParam(name, fmt.Sprintf("must list at most %d tags", validation.MaxQuoteTags))
+	}
+	return tags, nil
+}
+
+// attributionStatusesParam parses a comma separated list of distinct attribution statuses.
+func attributionStatusesParam(query url.Values, name string) ([]models.AttributionStatus, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var statuses []models.AttributionStatus
+	for _, value := range strings.Split(raw, ",") {
+		status := models.AttributionStatus(strings.TrimSpace(value))
+		if !models.AttributionStatuses[status] {
+			return nil, invalidParam(name, "must be one of verified, disputed, misattributed, unknown")
+		}
+		if !slices.Contains(statuses, status) {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses, nil
+}
+
+// timeParam parses an RFC 3339 timestamp or a date, which stands for midnight UTC.
+func timeParam(query url.Values, name string) (time.Time, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if v, err := time.Parse(time.RFC3339, raw); err == nil {
+		return v, nil
+	}
+	if v, err := time.Parse(time.DateOnly, raw); err == nil {
+		return v, nil
+	}
+	return time.Time{}, invalidParam(name, "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+}
+
+func invalidParam(name, message string) error {
+	return errors.New(errors.CodeInvalidArgument, fmt.Sprintf("query parameter %q: %s", name, message))
+}
+
+// pageLinks builds an RFC 8288 Link header value pointing to the first, next
+// and previous pages of the listing at r.
+func pageLinks(r *http.Request, page models.QuotePage) string {
+	link := func(rel, cursor string) string {
+		query := r.URL.Query()
+		query.Del("offset")
+		query.Del("cursor")
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		target := r.URL.Path
+		if encoded := query.Encode(); encoded != "" {
+			target += "?" + encoded
+		}
+		return fmt.Sprintf("<%s>; rel=%q", target, rel)
+	}
+
+	links := []string{link("first", "")}
+	if page.NextCursor != "" {
+		links = append(links, link("next", page.NextCursor))
+	}
+	if page.PrevCursor != "" {
+		links = append(links, link("prev", page.PrevCursor))
+	}
+	return strings.Join(links, ", ")
+}
+
+// randomSeedHeader carries the seed a random quote was drawn with.
+const randomSeedHeader = "X-Random-Seed"
+
+func validSeed(seed string) bool {
+	return printableToken(seed, models.MaxSeedLength)
+}
+
+func validClient(client string) bool {
+	return printableToken(client, models.MaxClientLength)
+}
+
+func newSeed() string {
+	return randomHex(8)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"quotemanager/pkg/errors"
+)
+
+// problem is an RFC 7807 problem details object.
+type problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []errors.FieldError `json:"errors,omitempty"`
+}
+
+// problemTypes maps error codes to the status and title of the problem type.
+var problemTypes = map[errors.Code]struct {
+	status int
+	title  string
+}{
+	errors.CodeInvalidArgument:      {http.StatusBadRequest, "Invalid request"},
+	errors.CodeNotFound:             {http.StatusNotFound, "Resource not found"},
+	errors.CodeValidation:           {http.StatusUnprocessableEntity, "Validation failed"},
+	errors.CodeConflict:             {http.StatusConflict, "Conflict"},
+	errors.CodePayloadTooLarge:      {http.StatusRequestEntityTooLarge, "Request body too large"},
+	errors.CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, "Unsupported media type"},
+	errors.CodeInternal:             {http.StatusInternalServerError, "Internal server error"},
+}
+
+// writeJSON encodes v as indented JSON and writes it with the given status code.
+func writeJSON(log *slog.Logger, w http.ResponseWriter, status int, v any) {
+	jsonData, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeError(log, w, errors.Wrap(errors.CodeInternal, err, "failed to encode response"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(append(jsonData, '\n')); err != nil {
+		log.Error("error writing", "error", err)
+	}
+}
+
+// writeError is the single place where failed requests are answered. It maps
+// err to an application/problem+json response using the code of the first
+// errors.Error in its chain; errors without a code are treated as internal
+// and their details are logged but never sent to the client.
+func writeError(log *slog.Logger, w http.ResponseWriter, err error) {
+	code := errors.CodeOf(err)
+	pt, ok := problemTypes[code]
+	if !ok {
+		code = errors.CodeInternal
+		pt = problemTypes[code]
+	}
+
+	p := problem{
+		Type:      "/problems/" + string(code),
+		Title:     pt.title,
+		Status:    pt.status,
+		Detail:    err.Error(),
+		RequestID: w.Header().Get(requestIDHeader),
+	}
+
+	if code == errors.CodeInternal {
+		log.Error("request failed", "error", err, "request_id", p.RequestID)
+		p.Detail = "The server failed to process the request"
+	} else {
+		log.Warn("request rejected", "error", err, "request_id", p.RequestID)
+	}
+
+	if appErr, ok := errors.As(err); ok {
+		p.Errors = appErr.Fields
+	}
+
+	jsonData, marshalErr := json.MarshalIndent(p, "", "  ")
+	if marshalErr != nil {
+		log.Error("failed to encode problem to JSON", "error", marshalErr)
+		http.Error(w, pt.title, pt.status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(pt.status)
+	if _, err := w.Write(append(jsonData, '\n')); err != nil {
+		log.Error("error writing", "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/validation"
+	"quotemanager/pkg/errors"
+)
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestWriteError(t *testing.T) {
+	testTable := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+		wantTitle  string
+		wantDetail string
+		wantFields []errors.FieldError
+	}{
+		{
+			name:       "Invalid argument",
+			err:        invalidParam("limit", "must be an integer"),
+			wantStatus: http.StatusBadRequest,
+			wantType:   "/problems/invalid-argument",
+			wantTitle:  "Invalid request",
+			wantDetail: `query parameter "limit": must be an integer`,
+		},
+		{
+			name:       "Wrapped not found",
+			err:        fmt.Errorf("failed to get quote 7: %w", errors.ErrQuoteNotFound),
+			wantStatus: http.StatusNotFound,
+			wantType:   "/problems/not-found",
+			wantTitle:  "Resource not found",
+			wantDetail: "failed to get quote 7: no quote was found",
+		},
+		{
+			name:       "Validation with fields",
+			err:        errors.Validation([]errors.FieldError{{Field: "author", Message: "is required"}}),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantType:   "/problems/validation",
+			wantTitle:  "Validation failed",
+			wantDetail: "validation failed",
+			wantFields: []errors.FieldError{{Field: "author", Message: "is required"}},
+		},
+		{
+			name:       "Conflict",
+			err:        errors.New(errors.CodeConflict, "tag already exists"),
+			wantStatus: http.StatusConflict,
+			wantType:   "/problems/conflict",
+			wantTitle:  "Conflict",
+			wantDetail: "tag already exists",
+		},
+		{
+			name:       "Payload too large",
+			err:        validation.ErrBodyTooLarge,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantType:   "/problems/payload-too-large",
+			wantTitle:  "Request body too large",
+			wantDetail: validation.ErrBodyTooLarge.Error(),
+		},
+		{
+			name:       "Unsupported media type",
+			err:        errors.New(errors.CodeUnsupportedMediaType, "unsupported content type"),
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantType:   "/problems/unsupported-media-type",
+			wantTitle:  "Unsupported media type",
+			wantDetail: "unsupported content type",
+		},
+		{
+			name:       "Internal error is masked",
+			err:        fmt.Errorf("failed to fetch quotes: %w", errors.Wrap(errors.CodeInternal, stdErrors.New("dial tcp 10.0.0.5:5432"), "db query error")),
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "/problems/internal",
+			wantTitle:  "Internal server error",
+			wantDetail: "The server failed to process the request",
+		},
+		{
+			name:       "Error without a code is masked",
+			err:        stdErrors.New("password authentication failed for user quotes"),
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "/problems/internal",
+			wantTitle:  "Internal server error",
+			wantDetail: "The server failed to process the request",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			w.Header().Set(requestIDHeader, "req-1")
+
+			writeError(newTestLogger(), w, testCase.err)
+
+			assert.Equal(t, testCase.wantStatus, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+			var p problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+			assert.Equal(t, problem{
+				Type:      testCase.wantType,
+				Title:     testCase.wantTitle,
+				Status:    testCase.wantStatus,
+				Detail:    testCase.wantDetail,
+				RequestID: "req-1",
+				Errors:    testCase.wantFields,
+			}, p)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func GetQuoteHistoryHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting quote history handler")
+		log.Info("Started getting quote history")
+
+		quoteID, err := parseQuoteID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		revisions, err := db.GetQuoteHistory(r.Context(), quoteID)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to get history of quote %d: %w", quoteID, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, revisions)
+		log.Info("Finished getting quote history", "quote_id", quoteID, "count", len(revisions))
+	}
+}
+
+func RevertQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Reverting quote handler")
+		log.Info("Started reverting quote")
+
+		quoteID, err := parseQuoteID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		raw := r.PathValue("rev")
+		rev, err := strconv.Atoi(raw)
+		if err != nil || rev <= 0 {
+			writeError(log, w, errors.New(errors.CodeInvalidArgument, fmt.Sprintf("invalid revision %q: must be a positive integer", raw)))
+			return
+		}
+
+		quote, err := db.RevertQuote(r.Context(), quoteID, rev)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to revert quote %d to revision %d: %w", quoteID, rev, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, quote)
+		log.Info("Finished reverting quote", "quote_id", quote.ID, "rev", rev)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"quotemanager/internal/repositories"
+	"quotemanager/internal/validation"
+	"quotemanager/pkg/errors"
+)
+
+// GetTagsHandler lists every tag with the number of live quotes carrying it.
+func GetTagsHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting tags handler")
+		log.Info("Started fetching tags")
+
+		tags, err := db.GetTags(r.Context())
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to fetch tags: %w", err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, tags)
+		log.Info("Finished fetching tags", "count", len(tags))
+	}
+}
+
+// RenameTagHandler renames the tag in the path to the tag in the "to" member of the body.
+func RenameTagHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Renaming tag handler")
+		log.Info("Started renaming tag")
+
+		from, err := parseTag(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		var request struct {
+			To string `json:"to"`
+		}
+		if err := validation.DecodeJSON(w, r, &request); err != nil {
+			writeError(log, w, err)
+			return
+		}
+		to, err := bodyTag("to", request.To)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		tag, err := db.RenameTag(r.Context(), from, to)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to rename tag %q: %w", from, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, tag)
+		log.Info("Finished renaming tag", "from", from, "to", to)
+	}
+}
+
+// MergeTagsHandler moves the quotes of the tag in the path to the existing
+// tag in the "into" member of the body and deletes the tag in the path.
+func MergeTagsHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Merging tags handler")
+		log.Info("Started merging tags")
+
+		from, err := parseTag(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		var request struct {
+			Into string `json:"into"`
+		}
+		if err := validation.DecodeJSON(w, r, &request); err != nil {
+			writeError(log, w, err)
+			return
+		}
+		into, err := bodyTag("into", request.Into)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		if into == from {
+			writeError(log, w, validation.Errors{{Field: "into", Message: "must differ from the merged tag"}}.OrNil())
+			return
+		}
+
+		tag, err := db.MergeTags(r.Context(), from, into)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to merge tag %q into %q: %w", from, into, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, tag)
+		log.Info("Finished merging tags", "from", from, "into", into)
+	}
+}
+
+// parseTag reads the tag path value as a slug.
+func parseTag(r *http.Request) (string, error) {
+	raw := r.PathValue("tag")
+	slug := validation.Tag(raw)
+	if problem := validation.CheckTag(slug); problem != "" {
+		return "", errors.New(errors.CodeInvalidArgument, fmt.Sprintf("invalid tag %q: %s", raw, problem))
+	}
+	return slug, nil
+}
+
+// bodyTag turns the tag in a body member into a slug.
+func bodyTag(field, tag string) (string, error) {
+	slug := validation.Tag(tag)
+	if problem := validation.CheckTag(slug); problem != "" {
+		return "", validation.Errors{{Field: field, Message: problem}}.OrNil()
+	}
+	return slug, nil
+}
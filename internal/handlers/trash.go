@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"quotemanager/internal/repositories"
+)
+
+// GetTrashHandler lists the deleted quotes with the filters and pagination of GetQuotesHandler.
+func GetTrashHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Getting trash handler")
+		log.Info("Started fetching trash")
+
+		filters, err := parseQuoteFilter(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+		filters.Trashed = true
+
+		page, err := db.GetQuotes(r.Context(), filters)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to fetch trash: %w", err))
+			return
+		}
+
+		w.Header().Set("Link", pageLinks(r, page))
+		writeJSON(log, w, http.StatusOK, page)
+		log.Info("Finished fetching trash")
+	}
+}
+
+func RestoreQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Restoring quote handler")
+		log.Info("Started restoring quote")
+
+		quoteID, err := parseQuoteID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		quote, err := db.RestoreQuote(r.Context(), quoteID)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to restore quote %d: %w", quoteID, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, quote)
+		log.Info("Finished restoring quote", "quote_id", quote.ID)
+	}
+}
+
+// PurgeQuoteHandler permanently deletes one quote from the trash.
+func PurgeQuoteHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Purging quote handler")
+		log.Info("Started purging quote")
+
+		quoteID, err := parseQuoteID(r)
+		if err != nil {
+			writeError(log, w, err)
+			return
+		}
+
+		if err := db.PurgeQuote(r.Context(), quoteID); err != nil {
+			writeError(log, w, fmt.Errorf("failed to purge quote %d: %w", quoteID, err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, map[string]string{
+			"message": fmt.Sprintf("quote with id %v was purged", quoteID),
+		})
+		log.Info("Finished purging quote")
+	}
+}
+
+// PurgeTrashHandler permanently deletes the quotes that have been in the
+// trash for longer than the required older_than duration.
+func PurgeTrashHandler(log *slog.Logger, db repositories.DBInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debug("Purging trash handler")
+		log.Info("Started purging trash")
+
+		raw := r.URL.Query().Get("older_than")
+		if raw == "" {
+			writeError(log, w, invalidParam("older_than", "is required"))
+			return
+		}
+		olderThan, err := time.ParseDuration(raw)
+		if err != nil || olderThan < 0 {
+			writeError(log, w, invalidParam("older_than", "must be a non-negative duration such as 720h"))
+			return
+		}
+
+		purged, err := db.PurgeTrash(r.Context(), olderThan)
+		if err != nil {
+			writeError(log, w, fmt.Errorf("failed to purge trash: %w", err))
+			return
+		}
+
+		writeJSON(log, w, http.StatusOK, map[string]int64{"purged": purged})
+		log.Info("Finished purging trash", "purged", purged)
+	}
+}
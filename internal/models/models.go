@@ -1,11 +1,453 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
 type Quote struct {
 	ID     int    `db:"id" json:"id"`
 	Quote  string `db:"quote" json:"quote"`
 	Author string `db:"author" json:"author"`
+	// AuthorID links the quote to its author, Author is the name of that author.
+	AuthorID int `db:"author_id" json:"author_id"`
+	// Weight is the curator weight used by weighted random selection.
+	Weight float64 `db:"weight" json:"weight"`
+	// Tags are the normalized slugs of the quote tags, sorted.
+	Tags []string `db:"tags" json:"tags"`
+	// Source cites where the quote comes from, every member is optional.
+	Source Source `db:"source" json:"source"`
+	// AttributionStatus tells whether the attribution to Author was checked.
+	AttributionStatus AttributionStatus `db:"attribution_status" json:"attribution_status"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	// DeletedAt is set on quotes in the trash.
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// Headline is an HTML escaped snippet of the quote with search matches
+	// wrapped in <mark> tags, only set in full-text search results.
+	Headline string `db:"-" json:"headline,omitempty"`
+}
+
+// Source is the citation of a quote. It is stored as a JSON object, so empty
+// members are left out.
+type Source struct {
+	// Title is the title of the work the quote appears in.
+	Title string `json:"title,omitempty"`
+	// Year is the publication year, negative before the common era.
+	Year *int   `json:"year,omitempty"`
+	Page string `json:"page,omitempty"`
+	URL  string `json:"url,omitempty"`
+	// Translator is set when the quote is a translation of OriginalText.
+	Translator   string `json:"translator,omitempty"`
+	OriginalText string `json:"original_text,omitempty"`
+}
+
+// SourceMembers are the JSON names of the members of a Source.
+var SourceMembers = []string{"title", "year", "page", "url", "translator", "original_text"}
+
+// SourcePatch is a JSON merge patch of a quote source. The members named in
+// Members take their value from Source, those left empty there are removed;
+// the other members are left untouched.
+type SourcePatch struct {
+	Members []string
+	Source  Source
+}
+
+// MarshalJSON encodes the patch as a merge patch object, with null for the
+// members to remove.
+func (p SourcePatch) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(p.Source)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	patch := make(map[string]json.RawMessage, len(p.Members))
+	for _, name := range p.Members {
+		patch[name] = json.RawMessage("null")
+		if value, ok := values[name]; ok {
+			patch[name] = value
+		}
+	}
+	return json.Marshal(patch)
+}
+
+// AttributionStatus is how far the attribution of a quote to its author is established.
+type AttributionStatus string
+
+const (
+	AttributionVerified      AttributionStatus = "verified"
+	AttributionDisputed      AttributionStatus = "disputed"
+	AttributionMisattributed AttributionStatus = "misattributed"
+	AttributionUnknown       AttributionStatus = "unknown"
+
+	DefaultAttributionStatus = AttributionUnknown
+)
+
+// AttributionStatuses is the whitelist of attribution statuses.
+var AttributionStatuses = map[AttributionStatus]bool{
+	AttributionVerified:      true,
+	AttributionDisputed:      true,
+	AttributionMisattributed: true,
+	AttributionUnknown:       true,
+}
+
+const (
+	// DefaultPageLimit is the page size used when the client does not ask for one.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest page size the server will return.
+	MaxPageLimit = 100
+)
+
+// Text search configurations supported for full-text search.
+const (
+	SearchEnglish = "english"
+	SearchRussian = "russian"
+
+	DefaultSearchLanguage = SearchEnglish
+)
+
+// SearchLanguages is the whitelist of text search configurations.
+var SearchLanguages = map[string]bool{
+	SearchEnglish: true,
+	SearchRussian: true,
+}
+
+// AuthorMatch selects how QuoteFilter.Author is compared with quote authors.
+type AuthorMatch string
+
+const (
+	AuthorMatchExact       AuthorMatch = "exact"
+	AuthorMatchInsensitive AuthorMatch = "insensitive"
+	AuthorMatchPrefix      AuthorMatch = "prefix"
+	AuthorMatchSubstring   AuthorMatch = "substring"
+	AuthorMatchFuzzy       AuthorMatch = "fuzzy"
+)
+
+// AuthorMatches is the whitelist of author matching modes.
+var AuthorMatches = map[AuthorMatch]bool{
+	AuthorMatchExact:       true,
+	AuthorMatchInsensitive: true,
+	AuthorMatchPrefix:      true,
+	AuthorMatchSubstring:   true,
+	AuthorMatchFuzzy:       true,
+}
+
+// TagMatch selects whether a quote needs any or all of QuoteFilter.Tags.
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any"
+	TagMatchAll TagMatch = "all"
+)
+
+// TagMatches is the whitelist of tag matching modes.
+var TagMatches = map[TagMatch]bool{
+	TagMatchAny: true,
+	TagMatchAll: true,
 }
 
 type QuoteFilter struct {
 	Author string `db:"author" json:"author"`
+	// AuthorMatch defaults to AuthorMatchExact. Exact and case-insensitive
+	// matching resolve Author to an author by name or alias, exact matching
+	// also compares the case.
+	AuthorMatch AuthorMatch `json:"author_match"`
+	// AuthorID keeps the quotes of one author, zero means any.
+	AuthorID int `json:"author_id"`
+	// AttributionStatuses keeps the quotes with any of these statuses.
+	AttributionStatuses []AttributionStatus `json:"attribution_status"`
+
+	// Search is a web-search style full-text query over the quote text,
+	// analysed with the Language text search configuration.
+	Search   string `json:"search"`
+	Language string `json:"language"`
+
+	// MinLength and MaxLength bound the quote length in characters, zero means unbounded.
+	MinLength int `json:"min_length"`
+	MaxLength int `json:"max_length"`
+
+	// CreatedAfter and CreatedBefore keep quotes created strictly after and
+	// strictly before the given instants, the zero time means unbounded.
+	CreatedAfter  time.Time `json:"created_after"`
+	CreatedBefore time.Time `json:"created_before"`
+
+	// Tags keeps quotes tagged with any (TagMatchAny, the default) or all
+	// (TagMatchAll) of the given slugs.
+	Tags     []string `json:"tags"`
+	TagMatch TagMatch `json:"tag_match"`
+
+	// Trashed lists the soft-deleted quotes instead of the live ones.
+	Trashed bool `json:"trashed"`
+
+	// Limit is the page size, Offset skips rows and Cursor continues from an
+	// opaque position returned in a previous QuotePage. Offset and Cursor are
+	// mutually exclusive.
+	Limit     int    `json:"limit"`
+	Offset    int    `json:"offset"`
+	Cursor    string `json:"cursor"`
+	WithTotal bool   `json:"with_total"`
+
+	// Sort lists the ordering keys, most significant first.
+	Sort []SortKey `json:"sort"`
+}
+
+// SortField names something quotes can be ordered by.
+type SortField string
+
+const (
+	SortByID        SortField = "id"
+	SortByAuthor    SortField = "author"
+	SortByCreatedAt SortField = "created_at"
+	SortByLength    SortField = "length"
+	// SortByPopularity orders by the curator weight, which also makes quotes
+	// more likely to be drawn in weighted random mode.
+	SortByPopularity SortField = "popularity"
+	// SortByRank orders by full-text search relevance and needs a search query.
+	SortByRank SortField = "rank"
+)
+
+// sortFields is the whitelist of fields accepted by ParseSort.
+var sortFields = map[SortField]bool{
+	SortByID:         true,
+	SortByAuthor:     true,
+	SortByCreatedAt:  true,
+	SortByLength:     true,
+	SortByPopularity: true,
+	SortByRank:       true,
+}
+
+// SortKey is one ordering key of a quote listing.
+type SortKey struct {
+	Field SortField `json:"field"`
+	Desc  bool      `json:"desc"`
+}
+
+func (k SortKey) String() string {
+	if k.Desc {
+		return string(k.Field) + ":desc"
+	}
+	return string(k.Field) + ":asc"
+}
+
+// ParseSort parses a comma separated list of sort keys such as
+// "author,-created_at" or "popularity:desc,id:asc". A leading "-" or a
+// ":desc" suffix selects descending order. Only whitelisted fields are accepted.
+func ParseSort(s string) ([]SortKey, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var keys []SortKey
+	seen := make(map[SortField]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+
+		var key SortKey
+		switch {
+		case strings.HasPrefix(part, "-"):
+			key = SortKey{Field: SortField(part[1:]), Desc: true}
+		case strings.HasPrefix(part, "+"):
+			key = SortKey{Field: SortField(part[1:])}
+		default:
+			name, dir, found := strings.Cut(part, ":")
+			key = SortKey{Field: SortField(name)}
+			if found {
+				switch strings.ToLower(dir) {
+				case "asc":
+				case "desc":
+					key.Desc = true
+				default:
+					return nil, fmt.Errorf("unknown sort direction %q, use asc or desc", dir)
+				}
+			}
+		}
+
+		if !sortFields[key.Field] {
+			return nil, fmt.Errorf("cannot sort by %q", key.Field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", key.Field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// QuotePage is one page of a quote listing.
+type QuotePage struct {
+	Quotes     []Quote `json:"quotes"`
+	Total      *int    `json:"total,omitempty"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
+}
+
+// QuotePatch describes a JSON merge patch of a quote: nil fields are left untouched.
+type QuotePatch struct {
+	Author *string  `json:"author"`
+	Quote  *string  `json:"quote"`
+	Weight *float64 `json:"weight"`
+	// Tags replaces every tag of the quote.
+	Tags *[]string `json:"tags"`
+	// Source is merged into the source of the quote.
+	Source            *SourcePatch       `json:"source"`
+	AttributionStatus *AttributionStatus `json:"attribution_status"`
+}
+
+const (
+	// DefaultQuoteWeight is the weight of quotes created without one.
+	DefaultQuoteWeight = 1
+	// MaxQuoteWeight is the largest curator weight of a quote.
+	MaxQuoteWeight = 1000
+)
+
+const (
+	// MaxRandomCount is the largest number of quotes returned by one random request.
+	MaxRandomCount = 50
+	// MaxSeedLength is the longest accepted random seed.
+	MaxSeedLength = 64
+	// MaxClientLength is the longest accepted rotation client token.
+	MaxClientLength = 64
+)
+
+// RandomMode selects the probability each matching quote is drawn with.
+type RandomMode string
+
+const (
+	// RandomUniform draws every matching quote with the same probability.
+	RandomUniform RandomMode = "uniform"
+	// RandomWeighted draws quotes proportionally to their weight and never
+	// draws quotes with a zero weight.
+	RandomWeighted RandomMode = "weighted"
+)
+
+// RandomModes is the whitelist of random selection modes.
+var RandomModes = map[RandomMode]bool{
+	RandomUniform:  true,
+	RandomWeighted: true,
+}
+
+// RandomOptions describes a random quote request.
+type RandomOptions struct {
+	// Filter restricts the pool quotes are drawn from, its pagination and
+	// sorting fields are ignored.
+	Filter QuoteFilter
+	// Count is the number of distinct quotes to draw, 1 by default.
+	Count int
+	// Mode defaults to RandomUniform.
+	Mode RandomMode
+	// Seed makes the draw reproducible: the same seed over the same quotes
+	// always picks the same ones. An empty seed draws from a random source.
+	Seed string
+	// Client identifies a rotation: quotes served to the same client from
+	// the same filter are not repeated until all of them were served.
+	Client string
+}
+
+// RevisionOperation is the change that produced a quote revision.
+type RevisionOperation string
+
+const (
+	RevisionCreate  RevisionOperation = "create"
+	RevisionUpdate  RevisionOperation = "update"
+	RevisionDelete  RevisionOperation = "delete"
+	RevisionRestore RevisionOperation = "restore"
+	RevisionRevert  RevisionOperation = "revert"
+)
+
+// QuoteRevision is the state of a quote after a change. Revisions are
+// numbered from 1 in the order they were made.
+type QuoteRevision struct {
+	Rev       int               `json:"rev"`
+	Operation RevisionOperation `json:"operation"`
+	Author    string            `json:"author"`
+	Quote     string            `json:"quote"`
+	Weight    float64           `json:"weight"`
+	CreatedAt time.Time         `json:"created_at"`
+	// Changes lists the fields that differ from the previous revision.
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is the old and the new value of a changed quote field.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// DefaultDailyTimezone is the time zone the quote of the day changes in by default.
+const DefaultDailyTimezone = "UTC"
+
+// DailyQuote is the quote of the day for a calendar day in a time zone.
+type DailyQuote struct {
+	Quote
+	Date     string `json:"date"`
+	Timezone string `json:"tz"`
+}
+
+const (
+	// DefaultSuggestLimit is the number of author suggestions returned by default.
+	DefaultSuggestLimit = 10
+	// MaxSuggestLimit is the largest number of author suggestions returned.
+	MaxSuggestLimit = 50
+)
+
+// AuthorSuggestion is an author completion with the number of their quotes.
+type AuthorSuggestion struct {
+	Author     string `db:"author" json:"author"`
+	QuoteCount int    `db:"quote_count" json:"quote_count"`
+}
+
+// Tag is a tag slug with the number of live quotes carrying it.
+type Tag struct {
+	Tag        string `db:"slug" json:"tag"`
+	QuoteCount int    `db:"quote_count" json:"quote_count"`
+}
+
+// Author is a person quotes are attributed to. The name and the aliases
+// identify the author case-insensitively, so quotes naming any of them are
+// linked to the same author.
+type Author struct {
+	ID          int      `db:"id" json:"id"`
+	Name        string   `db:"name" json:"name"`
+	Aliases     []string `db:"aliases" json:"aliases"`
+	BirthYear   *int     `db:"birth_year" json:"birth_year"`
+	DeathYear   *int     `db:"death_year" json:"death_year"`
+	Bio         string   `db:"bio" json:"bio"`
+	Nationality string   `db:"nationality" json:"nationality"`
+	// QuoteCount is the number of live quotes of the author.
+	QuoteCount int `db:"quote_count" json:"quote_count"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const (
+	// DefaultDuplicateSimilarity is the least name similarity of likely duplicate authors by default.
+	DefaultDuplicateSimilarity = 0.6
+	// DefaultDuplicateLimit is the number of likely duplicate authors returned by default.
+	DefaultDuplicateLimit = 50
+	// MaxDuplicateLimit is the largest number of likely duplicate authors returned.
+	MaxDuplicateLimit = 500
+	// MaxMergedAuthors is the largest number of authors merged at once.
+	MaxMergedAuthors = 100
+)
+
+// AuthorDuplicate is a pair of authors whose names or aliases look alike,
+// with the trigram similarity (0 to 1) of their closest names.
+type AuthorDuplicate struct {
+	AuthorID    int     `db:"author_id" json:"author_id"`
+	Author      string  `db:"author" json:"author"`
+	DuplicateID int     `db:"duplicate_id" json:"duplicate_id"`
+	Duplicate   string  `db:"duplicate" json:"duplicate"`
+	Similarity  float64 `db:"similarity" json:"similarity"`
 }
@@ -0,0 +1,82 @@
+package models_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"quotemanager/internal/models"
+)
+
+func TestParseSort(t *testing.T) {
+	testTable := []struct {
+		name     string
+		input    string
+		expected []models.SortKey
+		wantErr  bool
+	}{
+		{name: "Empty", input: "", expected: nil},
+		{
+			name:  "Prefix notation",
+			input: "author,-created_at",
+			expected: []models.SortKey{
+				{Field: models.SortByAuthor},
+				{Field: models.SortByCreatedAt, Desc: true},
+			},
+		},
+		{
+			name:  "Suffix notation",
+			input: "popularity:desc, length:ASC",
+			expected: []models.SortKey{
+				{Field: models.SortByPopularity, Desc: true},
+				{Field: models.SortByLength},
+			},
+		},
+		{name: "Unknown field", input: "author; DROP TABLE quotes", wantErr: true},
+		{name: "Unknown direction", input: "id:sideways", wantErr: true},
+		{name: "Duplicate field", input: "id,-id", wantErr: true},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			keys, err := models.ParseSort(testCase.input)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expected, keys)
+			}
+		})
+	}
+}
+
+func TestSourcePatch_MarshalJSON(t *testing.T) {
+	year := 65
+
+	testTable := []struct {
+		name     string
+		patch    models.SourcePatch
+		expected string
+	}{
+		{name: "Empty patch", patch: models.SourcePatch{}, expected: `{}`},
+		{
+			name:     "Set and removed members",
+			patch:    models.SourcePatch{Members: []string{"page", "title", "year"}, Source: models.Source{Page: "12", Year: &year}},
+			expected: `{"page":"12","title":null,"year":65}`,
+		},
+		{
+			name:     "Untouched members are left out",
+			patch:    models.SourcePatch{Members: []string{"page"}, Source: models.Source{Title: "Analects", Page: "17.2"}},
+			expected: `{"page":"17.2"}`,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			data, err := json.Marshal(testCase.patch)
+			assert.NoError(t, err)
+			assert.JSONEq(t, testCase.expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package repositories
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// resolveAuthor are WITH items resolving the author name given by the SQL
+// expression name to the WITH item resolved (id, name): the author with that
+// name or alias, or else a new author with that name. A NULL name resolves to
+// no row. The no-op update makes the insert return an existing author too.
+// A non-empty guard is an SQL condition the insert also needs, so that a
+// write matching no quote doesn't leave a new author behind.
+func resolveAuthor(name, guard string) string {
+	name = "(" + name + ")::text"
+	if guard != "" {
+		guard = " AND " + guard
+	}
+	return `
+		alias_match AS (
+			SELECT authors.id, authors.name
+			FROM author_aliases
+			JOIN authors ON authors.id = author_aliases.author_id
+			WHERE lower(author_aliases.alias) = lower(` + name + `)
+		), named AS (
+			INSERT INTO authors (name)
+			SELECT ` + name + `
+			WHERE ` + name + ` IS NOT NULL AND NOT EXISTS (SELECT 1 FROM alias_match)` + guard + `
+			ON CONFLICT ((lower(name))) DO UPDATE SET name = authors.name
+			RETURNING id, name
+		), resolved AS (
+			SELECT id, name FROM alias_match
+			UNION ALL
+			SELECT id, name FROM named
+		)`
+}
+
+// authorColumns selects an author from the row source src (an alias of
+// authors or a WITH item returning its columns), with the aliases given by
+// the SQL expression aliases.
+func authorColumns(src, aliases string) string {
+	return src + ".id, " + src + ".name, " + aliases + ", " + src + ".birth_year, " + src + ".death_year, " +
+		src + ".bio, " + src + ".nationality, " +
+		"(SELECT count(*) FROM quotes WHERE quotes.author_id = " + src + ".id AND quotes.deleted_at IS NULL), " +
+		src + ".created_at, " + src + ".updated_at"
+}
+
+// aliasesOf selects the sorted aliases of the author whose id is the SQL expression id.
+func aliasesOf(id string) string {
+	return "ARRAY(SELECT alias FROM author_aliases WHERE author_aliases.author_id = " + id + " ORDER BY alias)"
+}
+
+// authorReturning are the columns a write of authors returns for authorColumns.
+const authorReturning = "RETURNING id, name, birth_year, death_year, bio, nationality, created_at, updated_at"
+
+func scanAuthor(row pgx.Row) (models.Author, error) {
+	var a models.Author
+	err := row.Scan(&a.ID, &a.Name, &a.Aliases, &a.BirthYear, &a.DeathYear, &a.Bio, &a.Nationality, &a.QuoteCount, &a.CreatedAt, &a.UpdatedAt)
+	return a, err
+}
+
+// AddAuthor creates an author. A name or alias already used by another
+// author fails with ErrAuthorExists.
+func (db *DB) AddAuthor(ctx context.Context, author models.Author) (models.Author, error) {
+	db.Log.Debug("started adding author DB")
+
+	if err := db.checkAuthorNames(ctx, 0, author); err != nil {
+		return models.Author{}, err
+	}
+
+	query := `
+		WITH a AS (
+			INSERT INTO authors (name, birth_year, death_year, bio, nationality)
+			VALUES ($1, $2, $3, $4, $5)
+			` + authorReturning + `
+		), aliases AS (
+			INSERT INTO author_aliases (author_id, alias)
+			SELECT a.id, unnest($6::text[]) FROM a
+		)
+		SELECT ` + authorColumns("a", "$6::text[]") + ` FROM a
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "name", author.Name)
+
+	created, err := scanAuthor(db.Conn.QueryRow(ctx, query,
+		author.Name, author.BirthYear, author.DeathYear, author.Bio, author.Nationality, author.Aliases))
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			db.Log.Warn("author name is taken", "name", author.Name)
+			return models.Author{}, errors.ErrAuthorExists
+		}
+		db.Log.Error("failed to add author", "error", err)
+		return models.Author{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.Log.Debug("Finished adding author DB", "author_id", created.ID)
+	return created, nil
+}
+
+// GetAuthors returns a page of authors ordered by name.
+func (db *DB) GetAuthors(ctx context.Context, limit, offset int) ([]models.Author, error) {
+	db.Log.Debug("started getting authors DB")
+
+	query := `
+		SELECT ` + authorColumns("authors", aliasesOf("authors.id")) + `
+		FROM authors
+		ORDER BY lower(name), id
+		LIMIT $1 OFFSET $2
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "limit", limit, "offset", offset)
+
+	rows, err := db.Conn.Query(ctx, query, pageLimit(limit), offset)
+	if err != nil {
+		db.Log.Error("failed to fetch authors", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	defer rows.Close()
+
+	authors := []models.Author{}
+	for rows.Next() {
+		a, err := scanAuthor(rows)
+		if err != nil {
+			db.Log.Error("failed to scan author", "error", err)
+			return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		authors = append(authors, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.Log.Debug("ended getting authors DB", "count", len(authors))
+	return authors, nil
+}
+
+func (db *DB) GetAuthor(ctx context.Context, authorID int) (models.Author, error) {
+	db.Log.Debug("started getting author DB", "author_id", authorID)
+
+	query := `
+		SELECT ` + authorColumns("authors", aliasesOf("authors.id")) + `
+		FROM authors
+		WHERE id = $1
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "author_id", authorID)
+
+	author, err := scanAuthor(db.Conn.QueryRow(ctx, query, authorID))
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			db.Log.Warn("no author was found with the given id", "id", authorID)
+			return models.Author{}, errors.ErrAuthorNotFound
+		}
+		db.Log.Error("failed to fetch author", "error", err)
+		return models.Author{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.Log.Debug("ended getting author DB", "author_id", author.ID)
+	return author, nil
+}
+
+// UpdateAuthor replaces an author. A renamed author keeps the old name as an
+// alias, so quotes still naming it are linked to the author, and the new
+// name is written to all of its quotes, recording a revision for each.
+func (db *DB) UpdateAuthor(ctx context.Context, author models.Author) (models.Author, error) {
+	db.Log.Debug("started updating author DB", "author_id", author.ID)
+
+	current, err := db.GetAuthor(ctx, author.ID)
+	if err != nil {
+		return models.Author{}, err
+	}
+	if !strings.EqualFold(current.Name, author.Name) && !slices.ContainsFunc(author.Aliases, func(alias string) bool {
+		return strings.EqualFold(alias, current.Name)
+	}) {
+		author.Aliases = append(slices.Clone(author.Aliases), current.Name)
+		slices.Sort(author.Aliases)
+	}
+
+	if err := db.checkAuthorNames(ctx, author.ID, author); err != nil {
+		return models.Author{}, err
+	}
+
+	// Aliases are compared case-insensitively like the unique index on them,
+	// so changing only the case of an alias keeps the stored spelling.
+	query := `
+		WITH a AS (
+			UPDATE authors
+			SET name = $2, birth_year = $3, death_year = $4, bio = $5, nationality = $6, updated_at = now()
+			WHERE id = $1
+			` + authorReturning + `
+		), unaliased AS (
+			DELETE FROM author_aliases
+			WHERE author_id = $1 AND lower(alias) NOT IN (SELECT lower(unnest($7::text[])))
+		), aliased AS (
+			INSERT INTO author_aliases (author_id, alias)
+			SELECT a.id, unnest($7::text[]) FROM a
+			ON CONFLICT DO NOTHING
+		), q AS (
+			UPDATE quotes SET author = a.name, updated_at = now()
+			FROM a
+			WHERE quotes.author_id = a.id AND quotes.author <> a.name
+			RETURNING quotes.id, quotes.quote, quotes.author, quotes.weight
+		), ` + revisionItem(models.RevisionUpdate) + `
+		SELECT ` + authorColumns("a", "$7::text[]") + ` FROM a
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "author_id", author.ID)
+
+	updated, err := scanAuthor(db.Conn.QueryRow(ctx, query,
+		author.ID, author.Name, author.BirthYear, author.DeathYear, author.Bio, author.Nationality, author.Aliases))
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			db.Log.Warn("no author was found with the given id", "id", author.ID)
+			return models.Author{}, errors.ErrAuthorNotFound
+		}
+		if isPgError(err, uniqueViolation) {
+			db.Log.Warn("author name is taken", "name", author.Name)
+			return models.Author{}, errors.ErrAuthorExists
+		}
+		db.Log.Error("failed to update author", "error", err)
+		return models.Author{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.Log.Debug("Finished updating author DB", "author_id", updated.ID)
+	return updated, nil
+}
+
+// DeleteAuthor deletes an author without quotes, see ErrAuthorHasQuotes.
+func (db *DB) DeleteAuthor(ctx context.Context, authorID int) error {
+	db.Log.Debug("started deleting author DB", "author_id", authorID)
+
+	query := `DELETE FROM authors WHERE id = $1`
+
+	result, err := db.Conn.Exec(ctx, query, authorID)
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			db.Log.Warn("author still has quotes", "id", authorID)
+			return errors.ErrAuthorHasQuotes
+		}
+		db.Log.Error("failed to delete author", "error", err)
+		return fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		db.Log.Warn("no author was found with the given id", "id", authorID)
+		return errors.ErrAuthorNotFound
+	}
+
+	db.Log.Debug("Finished deleting author DB", "author_id", authorID)
+	return nil
+}
+
+// checkAuthorNames fails with ErrAuthorExists when an author other than
+// authorID has the name or one of the aliases of author as a name or alias.
+// The unique indexes only catch clashes between two names or two aliases.
+func (db *DB) checkAuthorNames(ctx context.Context, authorID int, author models.Author) error {
+	names := []string{strings.ToLower(author.Name)}
+	for _, alias := range author.Aliases {
+		names = append(names, strings.ToLower(alias))
+	}
+
+	query := `
+		SELECT EXISTS (SELECT 1 FROM authors WHERE id <> $1 AND lower(name) = ANY($2))
+			OR EXISTS (SELECT 1 FROM author_aliases WHERE author_id <> $1 AND lower(alias) = ANY($2))
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "author_id", authorID)
+
+	var taken bool
+	if err := db.Conn.QueryRow(ctx, query, authorID, names).Scan(&taken); err != nil {
+		db.Log.Error("failed to check author names", "error", err)
+		return fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	if taken {
+		db.Log.Warn("author name is taken", "name", author.Name)
+		return errors.ErrAuthorExists
+	}
+	return nil
+}
+
+// SuggestAuthors returns distinct authors whose name starts with prefix
+// (case-insensitively) together with their quote counts. An exact match comes
+// first, then the most quoted and the shortest names. The prefix lookup is
+// served by the idx_quotes_author_lower text_pattern_ops index.
+func (db *DB) SuggestAuthors(ctx context.Context, prefix string, limit int) ([]models.AuthorSuggestion, error) {
+	db.Log.Debug("started suggesting authors DB", "prefix", prefix)
+
+	prefix = strings.ToLower(prefix)
+
+	query := `
+		SELECT author, count(*) AS quote_count
+		FROM quotes
+		WHERE lower(author) LIKE $1 AND deleted_at IS NULL
+		GROUP BY author
+		ORDER BY lower(author) = $2 DESC, quote_count DESC, char_length(author), author
+		LIMIT $3
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "prefix", prefix, "limit", limit)
+
+	rows, err := db.Conn.Query(ctx, query, likeEscaper.Replace(prefix)+"%", prefix, limit)
+	if err != nil {
+		db.Log.Error("failed to suggest authors", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	defer rows.Close()
+
+	suggestions := []models.AuthorSuggestion{}
+	for rows.Next() {
+		var s models.AuthorSuggestion
+		if err := rows.Scan(&s.Author, &s.QuoteCount); err != nil {
+			db.Log.Error("failed to scan author suggestion", "error", err)
+			return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.Log.Debug("ended suggesting authors DB", "count", len(suggestions))
+	return suggestions, nil
+}
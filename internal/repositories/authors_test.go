@@ -0,0 +1,393 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func TestDB_SuggestAuthors(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const query = `SELECT author, count(*) AS quote_count FROM quotes WHERE lower(author) LIKE $1 AND deleted_at IS NULL GROUP BY author`
+
+	testTable := []struct {
+		name         string
+		prefix       string
+		mockBehavior func()
+		expected     []models.AuthorSuggestion
+		wantErr      bool
+	}{
+		{
+			name:   "OK",
+			prefix: "Con",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("con%", "con", 5).
+					WillReturnRows(pgxmock.NewRows([]string{"author", "quote_count"}).
+						AddRow("Confucius", 12).
+						AddRow("Constantine", 1))
+			},
+			expected: []models.AuthorSuggestion{
+				{Author: "Confucius", QuoteCount: 12},
+				{Author: "Constantine", QuoteCount: 1},
+			},
+		},
+		{
+			name:   "OK - No matches",
+			prefix: "zz",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("zz%", "zz", 5).
+					WillReturnRows(pgxmock.NewRows([]string{"author", "quote_count"}))
+			},
+			expected: []models.AuthorSuggestion{},
+		},
+		{
+			name:   "DB Error",
+			prefix: "Con",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("con%", "con", 5).
+					WillReturnError(errors.ErrQuery)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			suggestions, err := r.SuggestAuthors(context.Background(), testCase.prefix, 5)
+			if testCase.wantErr {
+				assert.ErrorIs(t, err, errors.ErrQuery)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testCase.expected, suggestions)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+// authorColumns are the columns of an author row in the order they are scanned.
+var authorColumns = []string{"id", "name", "aliases", "birth_year", "death_year", "bio", "nationality", "quote_count", "created_at", "updated_at"}
+
+const namesTakenQuery = `SELECT EXISTS (SELECT 1 FROM authors WHERE id <> $1 AND lower(name) = ANY($2)) OR EXISTS (SELECT 1 FROM author_aliases WHERE author_id <> $1 AND lower(alias) = ANY($2))`
+
+func TestDB_AddAuthor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const query = `INSERT INTO authors (name, birth_year, death_year, bio, nationality) VALUES ($1, $2, $3, $4, $5)`
+
+	author := models.Author{Name: "Seneca", Aliases: []string{"Seneca the Younger"}, BirthYear: ptr(-4), DeathYear: ptr(65), Nationality: "Roman"}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     models.Author
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(namesTakenQuery)).
+					WithArgs(0, []string{"seneca", "seneca the younger"}).
+					WillReturnRows(pgxmock.NewRows([]string{"taken"}).AddRow(false))
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("Seneca", author.BirthYear, author.DeathYear, "", "Roman", author.Aliases).
+					WillReturnRows(pgxmock.NewRows(authorColumns).
+						AddRow(3, "Seneca", author.Aliases, author.BirthYear, author.DeathYear, "", "Roman", 0, time.Time{}, time.Time{}))
+			},
+			expected: models.Author{ID: 3, Name: "Seneca", Aliases: []string{"Seneca the Younger"}, BirthYear: ptr(-4), DeathYear: ptr(65), Nationality: "Roman"},
+		},
+		{
+			name: "Alias of another author - ErrAuthorExists",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(namesTakenQuery)).
+					WithArgs(0, []string{"seneca", "seneca the younger"}).
+					WillReturnRows(pgxmock.NewRows([]string{"taken"}).AddRow(true))
+			},
+			expectedErr: errors.ErrAuthorExists,
+		},
+		{
+			name: "Concurrent insert - ErrAuthorExists",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(namesTakenQuery)).
+					WithArgs(0, []string{"seneca", "seneca the younger"}).
+					WillReturnRows(pgxmock.NewRows([]string{"taken"}).AddRow(false))
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs("Seneca", author.BirthYear, author.DeathYear, "", "Roman", author.Aliases).
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			expectedErr: errors.ErrAuthorExists,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.AddAuthor(context.Background(), author)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Author mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_GetAuthors(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM authors ORDER BY lower(name), id LIMIT $1 OFFSET $2`)).
+		WithArgs(models.DefaultPageLimit, 0).
+		WillReturnRows(pgxmock.NewRows(authorColumns).
+			AddRow(1, "Confucius", []string{"Kong Fuzi"}, nil, nil, "", "", 12, time.Time{}, time.Time{}).
+			AddRow(3, "Seneca", []string{}, nil, nil, "", "", 4, time.Time{}, time.Time{}))
+
+	authors, err := r.GetAuthors(context.Background(), 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Author{
+		{ID: 1, Name: "Confucius", Aliases: []string{"Kong Fuzi"}, QuoteCount: 12},
+		{ID: 3, Name: "Seneca", Aliases: []string{}, QuoteCount: 4},
+	}, authors)
+	assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+}
+
+func TestDB_GetAuthor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const query = `SELECT authors.id, authors.name, ARRAY(SELECT alias FROM author_aliases WHERE author_aliases.author_id = authors.id ORDER BY alias), authors.birth_year`
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     models.Author
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows(authorColumns).
+						AddRow(1, "Confucius", []string{"Kong Fuzi"}, ptr(-551), ptr(-479), "Chinese philosopher.", "Chinese", 12, time.Time{}, time.Time{}))
+			},
+			expected: models.Author{ID: 1, Name: "Confucius", Aliases: []string{"Kong Fuzi"}, BirthYear: ptr(-551), DeathYear: ptr(-479), Bio: "Chinese philosopher.", Nationality: "Chinese", QuoteCount: 12},
+		},
+		{
+			name: "Not Found - ErrAuthorNotFound",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expectedErr: errors.ErrAuthorNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.GetAuthor(context.Background(), 1)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Author mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_UpdateAuthor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		getQuery    = `FROM authors WHERE id = $1`
+		updateQuery = `UPDATE authors SET name = $2, birth_year = $3, death_year = $4, bio = $5, nationality = $6, updated_at = now() WHERE id = $1`
+		renameQuery = `UPDATE quotes SET author = a.name, updated_at = now() FROM a WHERE quotes.author_id = a.id AND quotes.author <> a.name RETURNING quotes.id, quotes.quote, quotes.author, quotes.weight ), rev AS ( INSERT INTO quote_revisions (quote_id, operation, author, quote, weight) SELECT id, 'update', author, quote, weight FROM q )`
+	)
+
+	current := func() *pgxmock.Rows {
+		return pgxmock.NewRows(authorColumns).
+			AddRow(1, "Kong Fuzi", []string{}, nil, nil, "", "", 12, time.Time{}, time.Time{})
+	}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     models.Author
+		expectedErr  error
+	}{
+		{
+			name: "OK - Renamed author keeps the old name as an alias",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+					WithArgs(1).
+					WillReturnRows(current())
+				mock.ExpectQuery(regexp.QuoteMeta(namesTakenQuery)).
+					WithArgs(1, []string{"confucius", "kong fuzi", "kongzi"}).
+					WillReturnRows(pgxmock.NewRows([]string{"taken"}).AddRow(false))
+				mock.ExpectQuery(regexp.QuoteMeta(updateQuery)+`.*`+regexp.QuoteMeta(renameQuery)).
+					WithArgs(1, "Confucius", (*int)(nil), (*int)(nil), "", "", []string{"Kong Fuzi", "Kongzi"}).
+					WillReturnRows(pgxmock.NewRows(authorColumns).
+						AddRow(1, "Confucius", []string{"Kong Fuzi", "Kongzi"}, nil, nil, "", "", 12, time.Time{}, time.Time{}))
+			},
+			expected: models.Author{ID: 1, Name: "Confucius", Aliases: []string{"Kong Fuzi", "Kongzi"}, QuoteCount: 12},
+		},
+		{
+			name: "Not Found - ErrAuthorNotFound",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+					WithArgs(1).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expectedErr: errors.ErrAuthorNotFound,
+		},
+		{
+			name: "Name of another author - ErrAuthorExists",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+					WithArgs(1).
+					WillReturnRows(current())
+				mock.ExpectQuery(regexp.QuoteMeta(namesTakenQuery)).
+					WithArgs(1, []string{"confucius", "kong fuzi", "kongzi"}).
+					WillReturnRows(pgxmock.NewRows([]string{"taken"}).AddRow(true))
+			},
+			expectedErr: errors.ErrAuthorExists,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.UpdateAuthor(context.Background(), models.Author{ID: 1, Name: "Confucius", Aliases: []string{"Kongzi"}})
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Author mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_DeleteAuthor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const query = `DELETE FROM authors WHERE id = $1`
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "Not Found - ErrAuthorNotFound",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			expectedErr: errors.ErrAuthorNotFound,
+		},
+		{
+			name: "Author with quotes - ErrAuthorHasQuotes",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnError(&pgconn.PgError{Code: "23503"})
+			},
+			expectedErr: errors.ErrAuthorHasQuotes,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actualErr := r.DeleteAuthor(context.Background(), 1)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
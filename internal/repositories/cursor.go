@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// was issued for a different ordering.
+var ErrInvalidCursor = errors.New(errors.CodeInvalidArgument, "invalid pagination cursor")
+
+// cursor is the keyset position of a page boundary: the sort key values of the
+// boundary row. Clients only ever see it as an opaque base64 token.
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	Before bool              `json:"b,omitempty"`
+}
+
+func encodeCursor(keys []models.SortKey, values []any, before bool) string {
+	c := cursor{Sort: sortSignature(keys), Before: before}
+	for _, v := range values {
+		raw, _ := json.Marshal(deref(v))
+		c.Values = append(c.Values, raw)
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses token for the given ordering and returns the boundary
+// values and whether the page lies before them.
+func decodeCursor(token string, keys []models.SortKey) ([]any, bool, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, false, ErrInvalidCursor
+	}
+	if c.Sort != sortSignature(keys) || len(c.Values) != len(keys) {
+		return nil, false, ErrInvalidCursor
+	}
+
+	values := make([]any, len(keys))
+	for i, k := range keys {
+		v, err := sortColumns[k.Field].kind.decode(c.Values[i])
+		if err != nil {
+			return nil, false, ErrInvalidCursor
+		}
+		values[i] = v
+	}
+	return values, c.Before, nil
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+const dailyDateLayout = "2006-01-02"
+
+// GetDailyQuote returns the quote of the day for the calendar day of date in
+// the time zone tz. The first request of a day picks a quote that was not
+// shown in tz since every quote was shown once, and records it in
+// daily_quotes so restarts and other replicas return the same quote.
+func (db *DB) GetDailyQuote(ctx context.Context, tz string, date time.Time) (models.DailyQuote, error) {
+	day := date.Format(dailyDateLayout)
+	db.Log.Debug("started getting daily quote DB", "tz", tz, "day", day)
+
+	daily, err := db.recordedDailyQuote(ctx, tz, day)
+	if err == nil {
+		db.Log.Debug("ended getting daily quote DB", "quote_id", daily.ID)
+		return daily, nil
+	}
+	if !stdErrors.Is(err, errors.ErrQuoteNotFound) {
+		return models.DailyQuote{}, err
+	}
+
+	cycle, ids, err := db.dailyCandidates(ctx, tz)
+	if err != nil {
+		return models.DailyQuote{}, err
+	}
+	if len(ids) == 0 {
+		db.Log.Warn("no quotes was found in DB")
+		return models.DailyQuote{}, errors.ErrQuoteNotFound
+	}
+
+	// Seeding with the day makes every replica pick the same quote, so a
+	// lost insert race below does not change the answer either way. The
+	// recorded quote is only replaced when it was moved to the trash.
+	picked := sampleIDs(newRand(tz+"/"+day), ids, 1)[0]
+
+	query := `
+		INSERT INTO daily_quotes (tz, day, quote_id, cycle)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tz, day) DO UPDATE
+		SET quote_id = EXCLUDED.quote_id, cycle = EXCLUDED.cycle
+		WHERE daily_quotes.quote_id IN (SELECT id FROM quotes WHERE deleted_at IS NOT NULL)
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "quote_id", picked, "cycle", cycle)
+
+	if _, err := db.Conn.Exec(ctx, query, tz, day, picked, cycle); err != nil {
+		db.Log.Error("failed to record daily quote", "error", err)
+		return models.DailyQuote{}, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+
+	daily, err = db.recordedDailyQuote(ctx, tz, day)
+	if err != nil {
+		return models.DailyQuote{}, err
+	}
+
+	db.Log.Debug("ended getting daily quote DB", "quote_id", daily.ID)
+	return daily, nil
+}
+
+// recordedDailyQuote returns the quote already picked for day in tz, or
+// ErrQuoteNotFound when there is none yet.
+func (db *DB) recordedDailyQuote(ctx context.Context, tz, day string) (models.DailyQuote, error) {
+	query := `
+		SELECT q.id, q.quote, q.author, q.weight, q.author_id, q.source, q.attribution_status, ` + tagsOf("q.id") + `, q.created_at, q.updated_at
+		FROM daily_quotes d
+		JOIN quotes q ON q.id = d.quote_id
+		WHERE d.tz = $1 AND d.day = $2 AND q.deleted_at IS NULL
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "tz", tz, "day", day)
+
+	daily := models.DailyQuote{Date: day, Timezone: tz}
+	err := db.Conn.QueryRow(ctx, query, tz, day).Scan(&daily.ID, &daily.Quote.Quote, &daily.Author, &daily.Weight, &daily.AuthorID, &daily.Source, &daily.AttributionStatus, &daily.Tags, &daily.CreatedAt, &daily.UpdatedAt)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return models.DailyQuote{}, errors.ErrQuoteNotFound
+		}
+		db.Log.Error("failed to fetch daily quote", "error", err)
+		return models.DailyQuote{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	return daily, nil
+}
+
+// dailyCandidates returns the current cycle of tz and the ids not shown in
+// it yet. Once every quote was shown, a new cycle starts with all of them.
+func (db *DB) dailyCandidates(ctx context.Context, tz string) (int, []int, error) {
+	var cycle int
+	query := `SELECT COALESCE(max(cycle), 1) FROM daily_quotes WHERE tz = $1`
+
+	db.Log.Debug("executing query", "query", query, "tz", tz)
+
+	if err := db.Conn.QueryRow(ctx, query, tz).Scan(&cycle); err != nil {
+		db.Log.Error("failed to fetch daily cycle", "error", err)
+		return 0, nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	b := liveQuotes()
+	b.addWhere("id NOT IN (SELECT quote_id FROM daily_quotes WHERE tz = " + b.arg(tz) + " AND cycle = " + b.arg(cycle) + ")")
+	ids, err := db.candidateIDs(ctx, b)
+	if err != nil || len(ids) > 0 {
+		return cycle, ids, err
+	}
+
+	db.Log.Debug("every quote was shown, starting a new daily cycle", "tz", tz, "cycle", cycle+1)
+	ids, err = db.candidateIDs(ctx, liveQuotes())
+	return cycle + 1, ids, err
+}
@@ -0,0 +1,151 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func TestDB_GetDailyQuote(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		tz  = "Europe/Moscow"
+		day = "2025-03-14"
+
+		recordedQuery = `SELECT q.id, q.quote, q.author, q.weight, q.author_id, q.source, q.attribution_status, ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = q.id ORDER BY t.slug), q.created_at, q.updated_at FROM daily_quotes d JOIN quotes q ON q.id = d.quote_id WHERE d.tz = $1 AND d.day = $2 AND q.deleted_at IS NULL`
+		cycleQuery    = `SELECT COALESCE(max(cycle), 1) FROM daily_quotes WHERE tz = $1`
+		unseenQuery   = `SELECT id FROM quotes WHERE deleted_at IS NULL AND id NOT IN (SELECT quote_id FROM daily_quotes WHERE tz = $1 AND cycle = $2) ORDER BY id`
+		allQuery      = `SELECT id FROM quotes WHERE deleted_at IS NULL ORDER BY id`
+		insertQuery   = `INSERT INTO daily_quotes (tz, day, quote_id, cycle) VALUES ($1, $2, $3, $4) ON CONFLICT (tz, day) DO UPDATE`
+	)
+
+	date := time.Date(2025, 3, 14, 23, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+	dailyRow := func() *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).AddRow(7, "Quote of the day", "Seneca", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+	}
+	expected := models.DailyQuote{
+		Quote:    models.Quote{ID: 7, Quote: "Quote of the day", Author: "Seneca", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+		Date:     day,
+		Timezone: tz,
+	}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     models.DailyQuote
+		expectedErr  error
+	}{
+		{
+			name: "OK - Already picked",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(recordedQuery)).
+					WithArgs(tz, day).
+					WillReturnRows(dailyRow())
+			},
+			expected: expected,
+		},
+		{
+			name: "OK - Picks an unseen quote",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(recordedQuery)).
+					WithArgs(tz, day).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}))
+				mock.ExpectQuery(regexp.QuoteMeta(cycleQuery)).
+					WithArgs(tz).
+					WillReturnRows(pgxmock.NewRows([]string{"cycle"}).AddRow(2))
+				mock.ExpectQuery(regexp.QuoteMeta(unseenQuery)).
+					WithArgs(tz, 2).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+					WithArgs(tz, day, 7, 2).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery(regexp.QuoteMeta(recordedQuery)).
+					WithArgs(tz, day).
+					WillReturnRows(dailyRow())
+			},
+			expected: expected,
+		},
+		{
+			name: "OK - Starts a new cycle when every quote was shown",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(recordedQuery)).
+					WithArgs(tz, day).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}))
+				mock.ExpectQuery(regexp.QuoteMeta(cycleQuery)).
+					WithArgs(tz).
+					WillReturnRows(pgxmock.NewRows([]string{"cycle"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(unseenQuery)).
+					WithArgs(tz, 1).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta(allQuery)).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+					WithArgs(tz, day, 7, 2).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery(regexp.QuoteMeta(recordedQuery)).
+					WithArgs(tz, day).
+					WillReturnRows(dailyRow())
+			},
+			expected: expected,
+		},
+		{
+			name: "No quotes - ErrQuoteNotFound",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(recordedQuery)).
+					WithArgs(tz, day).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}))
+				mock.ExpectQuery(regexp.QuoteMeta(cycleQuery)).
+					WithArgs(tz).
+					WillReturnRows(pgxmock.NewRows([]string{"cycle"}).AddRow(1))
+				mock.ExpectQuery(regexp.QuoteMeta(unseenQuery)).
+					WithArgs(tz, 1).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta(allQuery)).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}))
+			},
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+		{
+			name: "DB Error",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(recordedQuery)).
+					WithArgs(tz, day).
+					WillReturnError(errors.ErrQuery)
+			},
+			expectedErr: errors.ErrQuery,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.GetDailyQuote(context.Background(), tz, date)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Daily quote mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
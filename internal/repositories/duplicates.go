@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// normalizedName compares the names given by the SQL expression name
+// ignoring case, punctuation and spacing. Migration 000013 indexes it on
+// authors.name and author_aliases.alias for the % operator.
+func normalizedName(name string) string {
+	return "trim(regexp_replace(lower(" + name + "), '[^[:alnum:]]+', ' ', 'g'))"
+}
+
+// FindDuplicateAuthors lists the pairs of authors with a name or alias at
+// least minSimilarity alike, the most alike first. Every name looks up the
+// names like it through the trigram indexes with pg_trgm.similarity_threshold
+// set to minSimilarity for the transaction, and the similarity is checked
+// again on the matches.
+func (db *DB) FindDuplicateAuthors(ctx context.Context, minSimilarity float64, limit int) ([]models.AuthorDuplicate, error) {
+	db.Log.Debug("started finding duplicate authors DB", "min_similarity", minSimilarity)
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		db.Log.Error("failed to begin transaction", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+	// The transaction only scopes the threshold, it writes nothing.
+	defer tx.Rollback(ctx)
+
+	threshold := strconv.FormatFloat(minSimilarity, 'g', -1, 64)
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, threshold); err != nil {
+		db.Log.Error("failed to set similarity threshold", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+
+	query := `
+		WITH names AS (
+			SELECT id AS author_id, ` + normalizedName("name") + ` AS name FROM authors
+			UNION
+			SELECT author_id, ` + normalizedName("alias") + ` FROM author_aliases
+		), pairs AS (
+			SELECT a.author_id, b.author_id AS duplicate_id, max(similarity(a.name, b.name)) AS similarity
+			FROM names a
+			CROSS JOIN LATERAL (
+				SELECT id AS author_id, ` + normalizedName("name") + ` AS name FROM authors
+				WHERE ` + normalizedName("name") + ` % a.name
+				UNION ALL
+				SELECT author_id, ` + normalizedName("alias") + ` FROM author_aliases
+				WHERE ` + normalizedName("alias") + ` % a.name
+			) b
+			WHERE a.author_id < b.author_id AND similarity(a.name, b.name) >= $1
+			GROUP BY a.author_id, b.author_id
+		)
+		SELECT pairs.author_id, a.name, pairs.duplicate_id, d.name, pairs.similarity
+		FROM pairs
+		JOIN authors a ON a.id = pairs.author_id
+		JOIN authors d ON d.id = pairs.duplicate_id
+		ORDER BY pairs.similarity DESC, pairs.author_id, pairs.duplicate_id
+		LIMIT $2
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "min_similarity", minSimilarity, "limit", limit)
+
+	rows, err := tx.Query(ctx, query, minSimilarity, limit)
+	if err != nil {
+		db.Log.Error("failed to fetch duplicate authors", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	defer rows.Close()
+
+	duplicates := []models.AuthorDuplicate{}
+	for rows.Next() {
+		var d models.AuthorDuplicate
+		if err := rows.Scan(&d.AuthorID, &d.Author, &d.DuplicateID, &d.Duplicate, &d.Similarity); err != nil {
+			db.Log.Error("failed to scan duplicate authors", "error", err)
+			return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		duplicates = append(duplicates, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.Log.Debug("ended finding duplicate authors DB", "count", len(duplicates))
+	return duplicates, nil
+}
+
+// MergeAuthors merges the authors authorIDs into the author intoID: their
+// quotes, trashed ones included, are moved to it with a revision each, their
+// names and aliases become its aliases and they are deleted. The merge runs
+// in one transaction, so it is applied completely or not at all.
+func (db *DB) MergeAuthors(ctx context.Context, intoID int, authorIDs []int) (models.Author, error) {
+	db.Log.Debug("started merging authors DB", "into", intoID, "authors", authorIDs)
+
+	authorIDs = slices.Compact(slices.Sorted(slices.Values(authorIDs)))
+	authorIDs = slices.DeleteFunc(authorIDs, func(id int) bool { return id == intoID })
+
+	tx, err := db.Conn.Begin(ctx)
+	if err != nil {
+		db.Log.Error("failed to begin transaction", "error", err)
+		return models.Author{}, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+	// Rolling back after the commit is a no-op.
+	defer tx.Rollback(ctx)
+
+	// Locking the authors keeps concurrent writes from linking quotes to
+	// the merged authors before they are deleted.
+	rows, err := tx.Query(ctx, `SELECT id, name FROM authors WHERE id = $1 OR id = ANY($2) ORDER BY id FOR UPDATE`, intoID, authorIDs)
+	if err != nil {
+		db.Log.Error("failed to lock authors", "error", err)
+		return models.Author{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	var into string
+	names := make([]string, 0, len(authorIDs))
+	for rows.Next() {
+		var (
+			id   int
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			db.Log.Error("failed to scan author", "error", err)
+			return models.Author{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		if id == intoID {
+			into = name
+		} else {
+			names = append(names, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return models.Author{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	if into == "" || len(names) != len(authorIDs) {
+		db.Log.Warn("not all authors were found with the given ids", "into", intoID, "authors", authorIDs)
+		return models.Author{}, errors.ErrAuthorNotFound
+	}
+
+	statements := []struct {
+		name  string
+		query string
+		args  []any
+	}{
+		{
+			name: "move quotes",
+			query: `
+				WITH q AS (
+					UPDATE quotes SET author = $2, author_id = $1, updated_at = now()
+					WHERE author_id = ANY($3)
+					RETURNING id, quote, author, weight
+				)
+			` + revisionInsert(models.RevisionUpdate),
+			args: []any{intoID, into, authorIDs},
+		},
+		{
+			// Names and aliases are only checked against each other when
+			// authors are written, a concurrent write may have slipped by.
+			name:  "drop aliases naming the target",
+			query: `DELETE FROM author_aliases WHERE author_id = ANY($2) AND lower(alias) = lower($1)`,
+			args:  []any{into, authorIDs},
+		},
+		{
+			name:  "move aliases",
+			query: `UPDATE author_aliases SET author_id = $1 WHERE author_id = ANY($2)`,
+			args:  []any{intoID, authorIDs},
+		},
+		{
+			name:  "delete authors",
+			query: `DELETE FROM authors WHERE id = ANY($1)`,
+			args:  []any{authorIDs},
+		},
+		{
+			name:  "alias merged names",
+			query: `INSERT INTO author_aliases (author_id, alias) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING`,
+			args:  []any{intoID, names},
+		},
+	}
+	for _, statement := range statements {
+		db.Log.Debug("executing query", "step", statement.name, "query", strings.TrimSpace(statement.query))
+
+		if _, err := tx.Exec(ctx, statement.query, statement.args...); err != nil {
+			db.Log.Error("failed to merge authors", "step", statement.name, "error", err)
+			return models.Author{}, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		db.Log.Error("failed to commit author merge", "error", err)
+		return models.Author{}, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+
+	db.Log.Debug("Finished merging authors DB", "into", intoID, "merged", names)
+	return db.GetAuthor(ctx, intoID)
+}
@@ -0,0 +1,207 @@
+package repositories_test
+
+import (
+	"context"
+	stdErrors "errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func TestDB_FindDuplicateAuthors(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		thresholdQuery = `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`
+		query          = `WHERE a.author_id < b.author_id AND similarity(a.name, b.name) >= $1 GROUP BY a.author_id, b.author_id`
+		lookupQuery    = `WHERE trim(regexp_replace(lower(name), '[^[:alnum:]]+', ' ', 'g')) % a.name`
+	)
+	expectThreshold := func() {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(thresholdQuery)).
+			WithArgs("0.6").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     []models.AuthorDuplicate
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				expectThreshold()
+				mock.ExpectQuery(regexp.QuoteMeta(lookupQuery)+`.*`+regexp.QuoteMeta(query)).
+					WithArgs(0.6, 50).
+					WillReturnRows(pgxmock.NewRows([]string{"author_id", "author", "duplicate_id", "duplicate", "similarity"}).
+						AddRow(1, "Seneca", 4, "Seneca.", 1.0).
+						AddRow(2, "Confucius", 3, "Confucious", 0.75))
+				mock.ExpectRollback()
+			},
+			expected: []models.AuthorDuplicate{
+				{AuthorID: 1, Author: "Seneca", DuplicateID: 4, Duplicate: "Seneca.", Similarity: 1},
+				{AuthorID: 2, Author: "Confucius", DuplicateID: 3, Duplicate: "Confucious", Similarity: 0.75},
+			},
+		},
+		{
+			name: "OK - No duplicates",
+			mockBehavior: func() {
+				expectThreshold()
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(0.6, 50).
+					WillReturnRows(pgxmock.NewRows([]string{"author_id", "author", "duplicate_id", "duplicate", "similarity"}))
+				mock.ExpectRollback()
+			},
+			expected: []models.AuthorDuplicate{},
+		},
+		{
+			name: "DB Error",
+			mockBehavior: func() {
+				expectThreshold()
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(0.6, 50).
+					WillReturnError(errors.ErrQuery)
+				mock.ExpectRollback()
+			},
+			expectedErr: errors.ErrQuery,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.FindDuplicateAuthors(context.Background(), 0.6, 50)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Duplicates mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_MergeAuthors(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		lockQuery   = `SELECT id, name FROM authors WHERE id = $1 OR id = ANY($2) ORDER BY id FOR UPDATE`
+		quotesQuery = `UPDATE quotes SET author = $2, author_id = $1, updated_at = now() WHERE author_id = ANY($3) RETURNING id, quote, author, weight ) INSERT INTO quote_revisions`
+		getQuery    = `FROM authors WHERE id = $1`
+	)
+
+	// The duplicated id and the target among the merged ids are ignored.
+	merged := []int{4, 1, 3, 4}
+
+	lock := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(lockQuery)).
+			WithArgs(3, []int{1, 4}).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).
+				AddRow(1, "Konfuzius").
+				AddRow(3, "Confucius").
+				AddRow(4, "Confucious"))
+	}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     models.Author
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				lock()
+				mock.ExpectExec(regexp.QuoteMeta(quotesQuery)).
+					WithArgs(3, "Confucius", []int{1, 4}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 5))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM author_aliases WHERE author_id = ANY($2) AND lower(alias) = lower($1)`)).
+					WithArgs("Confucius", []int{1, 4}).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE author_aliases SET author_id = $1 WHERE author_id = ANY($2)`)).
+					WithArgs(3, []int{1, 4}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM authors WHERE id = ANY($1)`)).
+					WithArgs([]int{1, 4}).
+					WillReturnResult(pgxmock.NewResult("DELETE", 2))
+				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO author_aliases (author_id, alias) SELECT $1, unnest($2::text[]) ON CONFLICT DO NOTHING`)).
+					WithArgs(3, []string{"Konfuzius", "Confucious"}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta(getQuery)).
+					WithArgs(3).
+					WillReturnRows(pgxmock.NewRows(authorColumns).
+						AddRow(3, "Confucius", []string{"Confucious", "Kong Fuzi", "Konfuzius"}, nil, nil, "", "", 17, time.Time{}, time.Time{}))
+			},
+			expected: models.Author{ID: 3, Name: "Confucius", Aliases: []string{"Confucious", "Kong Fuzi", "Konfuzius"}, QuoteCount: 17},
+		},
+		{
+			name: "Unknown author - ErrAuthorNotFound",
+			mockBehavior: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(lockQuery)).
+					WithArgs(3, []int{1, 4}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).
+						AddRow(3, "Confucius").
+						AddRow(4, "Confucious"))
+				mock.ExpectRollback()
+			},
+			expectedErr: errors.ErrAuthorNotFound,
+		},
+		{
+			name: "Failed step - rolled back",
+			mockBehavior: func() {
+				lock()
+				mock.ExpectExec(regexp.QuoteMeta(quotesQuery)).
+					WithArgs(3, "Confucius", []int{1, 4}).
+					WillReturnError(stdErrors.New("deadlock detected"))
+				mock.ExpectRollback()
+			},
+			expectedErr: errors.ErrExecDB,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.MergeAuthors(context.Background(), 3, merged)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Author mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
@@ -3,56 +3,196 @@ package repositories
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"quotemanager/migrations"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/pgx"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 )
 
+// Migrator applies the embedded schema migrations to the database of a DB.
+type Migrator struct {
+	log   *slog.Logger
+	m     *migrate.Migrate
+	files source.Driver
+}
+
+// ErrSchemaVersion is returned by CheckSchema when the database is not
+// migrated to the version the embedded migrations expect.
+var ErrSchemaVersion = errors.New("unexpected schema version")
+
+// Migrate applies every pending migration.
 func (db *DB) Migrate() error {
 	db.Log.Debug("running migration")
 
+	m, err := db.Migrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		return err
+	}
+
+	db.Log.Debug("migration finished")
+	return nil
+}
+
+// Migrator prepares a Migrator, it has to be closed after use.
+func (db *DB) Migrator() (*Migrator, error) {
 	pool, ok := db.Conn.(*pgxpool.Pool)
 	if !ok {
 		err := errors.New("db.Conn is not of type *pgxpool.Pool, cannot run migrations")
 		db.Log.Error("type assertion failed for db.Conn to *pgxpool.Pool", "actual_type", fmt.Sprintf("%T", db.Conn), "error", err)
-		return err
+		return nil, err
 	}
 
 	files, err := iofs.New(migrations.MigrationFiles, ".")
 	if err != nil {
 		db.Log.Error("failed to load migration files", "error", err)
-		return err
+		return nil, err
 	}
 	db.Log.Debug("migration files loaded successfully")
 
 	sqlDB := stdlib.OpenDBFromPool(pool)
-	defer sqlDB.Close()
 
 	driver, err := pgx.WithInstance(sqlDB, &pgx.Config{})
 	if err != nil {
+		sqlDB.Close()
 		db.Log.Error("failed to create pgx driver for migrations", "error", err)
-		return err
+		return nil, err
 	}
 	m, err := migrate.NewWithInstance("iofs", files, "pgx", driver)
 	if err != nil {
+		sqlDB.Close()
 		db.Log.Error("failed to initialize migrations", "error", err)
+		return nil, err
+	}
+
+	return &Migrator{log: db.Log, m: m, files: files}, nil
+}
+
+// CheckSchema verifies, without changing anything, that every embedded
+// migration is applied and that the last one did not fail halfway.
+func (db *DB) CheckSchema() error {
+	m, err := db.Migrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	latest, err := m.Latest()
+	if err != nil {
+		return err
+	}
+
+	if err := CheckSchemaVersion(version, latest, dirty); err != nil {
 		return err
 	}
 
-	err = m.Up()
+	db.Log.Debug("schema version checked", "version", version)
+	return nil
+}
+
+// CheckSchemaVersion fails with ErrSchemaVersion unless the applied version
+// is the latest embedded one and the last migration did not fail halfway.
+func CheckSchemaVersion(version, latest uint, dirty bool) error {
+	switch {
+	case dirty:
+		return fmt.Errorf("%w: version %d is dirty, a migration failed halfway; repair the schema and run migrate force", ErrSchemaVersion, version)
+	case version < latest:
+		return fmt.Errorf("%w: database is at version %d but this binary expects %d; run migrate up", ErrSchemaVersion, version, latest)
+	case version > latest:
+		return fmt.Errorf("%w: database is at version %d but this binary only knows up to %d; deploy a matching binary", ErrSchemaVersion, version, latest)
+	}
+	return nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() error {
+	return m.run("up", m.m.Up)
+}
+
+// Down reverts the last steps applied migrations.
+func (m *Migrator) Down(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("down needs a positive number of steps, got %d", steps)
+	}
+	return m.run("down", func() error { return m.m.Steps(-steps) })
+}
+
+// Goto migrates up or down to the given version.
+func (m *Migrator) Goto(version uint) error {
+	return m.run("goto", func() error { return m.m.Migrate(version) })
+}
+
+// Force sets the recorded version and clears the dirty flag without running
+// any migration, to recover after a migration failed halfway. A version of
+// -1 records that no migration is applied.
+func (m *Migrator) Force(version int) error {
+	return m.run("force", func() error { return m.m.Force(version) })
+}
 
+// Latest returns the version of the last embedded migration.
+func (m *Migrator) Latest() (uint, error) {
+	version, err := m.files.First()
 	if err != nil {
-		if err != migrate.ErrNoChange {
-			db.Log.Error("migration failed", "error", err)
+		m.log.Error("failed to read migration files", "error", err)
+		return 0, err
+	}
+	for {
+		next, err := m.files.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			m.log.Error("failed to read migration files", "error", err)
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// Version returns the applied version, zero when no migration was applied,
+// and whether the last migration failed halfway.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		m.log.Error("failed to read migration version", "error", err)
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
+
+// Close releases the database connection of the migrator, the pool of the DB stays open.
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+func (m *Migrator) run(name string, migration func() error) error {
+	m.log.Debug("running migration", "command", name)
+
+	if err := migration(); err != nil {
+		if !errors.Is(err, migrate.ErrNoChange) {
+			m.log.Error("migration failed", "command", name, "error", err)
 			return err
 		}
-		db.Log.Debug("migration did not change anything")
+		m.log.Debug("migration did not change anything", "command", name)
 	}
 
-	db.Log.Debug("migration finished")
 	return nil
 }
@@ -0,0 +1,38 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"quotemanager/internal/repositories"
+)
+
+func TestCheckSchemaVersion(t *testing.T) {
+	testTable := []struct {
+		name    string
+		version uint
+		latest  uint
+		dirty   bool
+		wantErr string
+	}{
+		{name: "OK - Up to date", version: 13, latest: 13},
+		{name: "Behind", version: 12, latest: 13, wantErr: "run migrate up"},
+		{name: "Nothing applied", version: 0, latest: 13, wantErr: "run migrate up"},
+		{name: "Ahead", version: 14, latest: 13, wantErr: "deploy a matching binary"},
+		{name: "Dirty", version: 13, latest: 13, dirty: true, wantErr: "run migrate force"},
+		{name: "Dirty and behind", version: 12, latest: 13, dirty: true, wantErr: "run migrate force"},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := repositories.CheckSchemaVersion(testCase.version, testCase.latest, testCase.dirty)
+			if testCase.wantErr != "" {
+				assert.ErrorIs(t, err, repositories.ErrSchemaVersion)
+				assert.ErrorContains(t, err, testCase.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"strconv"
+	"strings"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// queryBuilder accumulates FROM items, WHERE conditions and their positional
+// arguments of a query over quotes.
+type queryBuilder struct {
+	joins []string
+	where []string
+	args  []any
+}
+
+// arg registers v as the next positional argument and returns its placeholder.
+func (b *queryBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuilder) addWhere(condition string) {
+	b.where = append(b.where, condition)
+}
+
+// addFrom adds an item to the FROM list after quotes.
+func (b *queryBuilder) addFrom(item string) {
+	b.joins = append(b.joins, item)
+}
+
+// fromSQL renders the FROM clause.
+func (b *queryBuilder) fromSQL() string {
+	if len(b.joins) == 0 {
+		return " FROM quotes"
+	}
+	return " FROM quotes, " + strings.Join(b.joins, ", ")
+}
+
+// whereSQL renders the accumulated conditions, or nothing when there are none.
+func (b *queryBuilder) whereSQL() string {
+	if len(b.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.where, " AND ")
+}
+
+// clone returns a copy that can be extended without affecting b.
+func (b *queryBuilder) clone() *queryBuilder {
+	return &queryBuilder{
+		joins: append([]string(nil), b.joins...),
+		where: append([]string(nil), b.where...),
+		args:  append([]any(nil), b.args...),
+	}
+}
+
+// liveCondition keeps the quotes that are not in the trash.
+const liveCondition = "deleted_at IS NULL"
+
+// lockLiveQuote is a WITH item named live locking the quote whose id is the
+// SQL expression id unless it is in the trash. Writes guard their side
+// effects on EXISTS (SELECT 1 FROM live), which also holds off a concurrent
+// delete of the quote until they are done. It also returns the author, quote
+// and weight before the write, see changedRevisionItem.
+func lockLiveQuote(id string) string {
+	return "live AS (SELECT id, author, quote, weight FROM quotes WHERE id = " + id + " AND " + liveCondition + " FOR UPDATE)"
+}
+
+// liveGuard is the condition that the quote locked by lockLiveQuote exists.
+const liveGuard = "EXISTS (SELECT 1 FROM live)"
+
+// liveQuotes returns a builder selecting every quote that is not in the trash.
+func liveQuotes() *queryBuilder {
+	b := &queryBuilder{}
+	b.addWhere(liveCondition)
+	return b
+}
+
+// ErrUnknownAuthorMatch is returned for an author matching mode that is not supported.
+var ErrUnknownAuthorMatch = errors.New(errors.CodeInvalidArgument, "unsupported author matching mode")
+
+// likeEscaper escapes the LIKE wildcards so user input is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// addAuthorCondition matches quote authors against author. Exact and
+// case-insensitive matching resolve author as the name or an alias of an
+// author through idx_authors_name_lower and idx_author_aliases_alias_lower,
+// exact matching then rechecks the case, and use idx_quotes_author_id.
+// Prefix matching uses
+// idx_quotes_author_lower, substring and fuzzy matching use the pg_trgm index
+// idx_quotes_author_trgm, all three on the canonical author name.
+func addAuthorCondition(b *queryBuilder, author string, match models.AuthorMatch) error {
+	switch match {
+	case "", models.AuthorMatchExact:
+		name := b.arg(author)
+		b.addWhere("author_id IN (SELECT id FROM authors WHERE lower(name) = lower(" + name + ") AND name = " + name +
+			" UNION ALL SELECT author_id FROM author_aliases WHERE lower(alias) = lower(" + name + ") AND alias = " + name + ")")
+	case models.AuthorMatchInsensitive:
+		name := b.arg(author)
+		b.addWhere("author_id IN (SELECT id FROM authors WHERE lower(name) = lower(" + name + ")" +
+			" UNION ALL SELECT author_id FROM author_aliases WHERE lower(alias) = lower(" + name + "))")
+	case models.AuthorMatchPrefix:
+		b.addWhere("lower(author) LIKE " + b.arg(likeEscaper.Replace(strings.ToLower(author))+"%"))
+	case models.AuthorMatchSubstring:
+		b.addWhere("author ILIKE " + b.arg("%"+likeEscaper.Replace(author)+"%"))
+	case models.AuthorMatchFuzzy:
+		b.addWhere("author % " + b.arg(author))
+	default:
+		return ErrUnknownAuthorMatch
+	}
+	return nil
+}
+
+// searchColumns maps text search configurations to their generated tsvector columns.
+var searchColumns = map[string]string{
+	models.SearchEnglish: "search_english",
+	models.SearchRussian: "search_russian",
+}
+
+// ErrUnknownSearchLanguage is returned for a text search configuration without a tsvector column.
+var ErrUnknownSearchLanguage = errors.New(errors.CodeInvalidArgument, "unsupported search language")
+
+// applyQuoteFilter adds the row-selecting part of filters (not pagination).
+// A full-text search also makes search.config, search.query and rank
+// available to the rest of the query.
+func applyQuoteFilter(b *queryBuilder, filters models.QuoteFilter) error {
+	if filters.Trashed {
+		b.addWhere("deleted_at IS NOT NULL")
+	} else {
+		b.addWhere(liveCondition)
+	}
+
+	if filters.Author != "" {
+		if err := addAuthorCondition(b, filters.Author, filters.AuthorMatch); err != nil {
+			return err
+		}
+	}
+
+	if filters.AuthorID > 0 {
+		b.addWhere("author_id = " + b.arg(filters.AuthorID))
+	}
+
+	if len(filters.Tags) > 0 {
+		if err := addTagCondition(b, filters.Tags, filters.TagMatch); err != nil {
+			return err
+		}
+	}
+
+	if len(filters.AttributionStatuses) > 0 {
+		b.addWhere("attribution_status = ANY(" + b.arg(filters.AttributionStatuses) + ")")
+	}
+
+	if filters.Search != "" {
+		language := filters.Language
+		if language == "" {
+			language = models.DefaultSearchLanguage
+		}
+		column, ok := searchColumns[language]
+		if !ok {
+			return ErrUnknownSearchLanguage
+		}
+
+		config := b.arg(language)
+		b.addFrom("(SELECT " + config + "::regconfig AS config, websearch_to_tsquery(" + config + "::regconfig, " + b.arg(filters.Search) + ") AS query) AS search")
+		b.addFrom("ts_rank(quotes." + column + ", search.query) AS rank")
+		b.addWhere("quotes." + column + " @@ search.query")
+	}
+
+	if filters.MinLength > 0 {
+		b.addWhere("char_length(quote) >= " + b.arg(filters.MinLength))
+	}
+	if filters.MaxLength > 0 {
+		b.addWhere("char_length(quote) <= " + b.arg(filters.MaxLength))
+	}
+
+	if !filters.CreatedAfter.IsZero() {
+		b.addWhere("created_at > " + b.arg(filters.CreatedAfter))
+	}
+	if !filters.CreatedBefore.IsZero() {
+		b.addWhere("created_at < " + b.arg(filters.CreatedBefore))
+	}
+
+	return nil
+}
+
+// pageLimit returns the requested page size clamped to the allowed range.
+func pageLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return models.DefaultPageLimit
+	case limit > models.MaxPageLimit:
+		return models.MaxPageLimit
+	default:
+		return limit
+	}
+}
@@ -0,0 +1,578 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// RandomStrategy selects how random quotes are picked from the whole table.
+// Filtered requests always sample the ids matching the filter, see
+// sampleFiltered, weighted requests on the whole table draw from the cached
+// weights, see weightIndex, and client rotations the ids not served yet, see
+// randomRotation.
+type RandomStrategy string
+
+const (
+	// RandomProbe draws ids uniformly between the smallest and the largest id
+	// and keeps the drawn ids that exist. Every draw is a primary key lookup,
+	// so the cost does not depend on the table size; when ids are too sparse
+	// for probing it falls back to sampling the list of ids.
+	RandomProbe RandomStrategy = "probe"
+	// RandomIDIndex keeps every id in memory and draws from them. The index
+	// is rebuilt after writes through this DB and at least every idIndexTTL to
+	// pick up writes made by other replicas.
+	RandomIDIndex RandomStrategy = "index"
+	// RandomOrderBy reads every matching row on each call and is only kept to
+	// benchmark the other strategies against. Unseeded calls sort the rows by
+	// random(). Seeded ones sample the id list instead, as random() ignores the
+	// seed; that includes every HTTP request, which always gets a seed to
+	// report in X-Random-Seed.
+	RandomOrderBy RandomStrategy = "order_by"
+)
+
+const (
+	// At least probeBatch ids are looked up per round trip, for up to probeRounds round trips.
+	probeBatch  = 16
+	probeRounds = 4
+
+	idIndexTTL = time.Minute
+)
+
+// ErrUnknownRandomMode is returned for a random selection mode that is not supported.
+var ErrUnknownRandomMode = errors.New(errors.CodeInvalidArgument, "unsupported random selection mode")
+
+// Valid reports whether s is a known strategy.
+func (s RandomStrategy) Valid() bool {
+	switch s {
+	case RandomProbe, RandomIDIndex, RandomOrderBy:
+		return true
+	}
+	return false
+}
+
+// GetRandomQuotes returns up to opts.Count distinct quotes drawn uniformly
+// from the quotes matching opts.Filter. It fails with ErrQuoteNotFound when
+// nothing matches. Requests with the same opts.Seed get the same quotes as
+// long as the quotes and the strategy do not change.
+func (db *DB) GetRandomQuotes(ctx context.Context, opts models.RandomOptions) ([]models.Quote, error) {
+	strategy := db.RandomStrategy
+	if strategy == "" {
+		strategy = RandomProbe
+	}
+	count := min(max(opts.Count, 1), models.MaxRandomCount)
+	if opts.Mode != "" && !models.RandomModes[opts.Mode] {
+		return nil, ErrUnknownRandomMode
+	}
+	weighted := opts.Mode == models.RandomWeighted
+	db.Log.Debug("started getting random quotes DB", "strategy", strategy, "count", count, "seed", opts.Seed)
+
+	b := &queryBuilder{}
+	if err := applyQuoteFilter(b, opts.Filter); err != nil {
+		return nil, err
+	}
+	filtered := !slices.Equal(b.where, []string{liveCondition})
+
+	rng := newRand(opts.Seed)
+
+	var (
+		quotes []models.Quote
+		err    error
+	)
+	switch {
+	case opts.Client != "":
+		quotes, err = db.randomRotation(ctx, b, rng, count, opts.Client, weighted)
+	case weighted && filtered:
+		quotes, err = db.sampleFiltered(ctx, b, rng, count, true)
+	case weighted:
+		quotes, err = db.randomByWeightIndex(ctx, rng, count)
+	case strategy == RandomOrderBy && opts.Seed == "":
+		quotes, err = db.randomByOrder(ctx, b, count)
+	case filtered || strategy == RandomOrderBy:
+		quotes, err = db.sampleFiltered(ctx, b, rng, count, false)
+	case strategy == RandomIDIndex:
+		quotes, err = db.randomByIDIndex(ctx, rng, count)
+	default:
+		quotes, err = db.randomByProbe(ctx, rng, count)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(quotes) == 0 {
+		db.Log.Warn("no quotes was found in DB")
+		return nil, errors.ErrQuoteNotFound
+	}
+
+	db.Log.Debug("ended getting random quotes DB", "count", len(quotes))
+	return quotes, nil
+}
+
+func (db *DB) randomByProbe(ctx context.Context, rng *rand.Rand, count int) ([]models.Quote, error) {
+	var minID, maxID *int
+	query := `SELECT min(id), max(id) FROM quotes WHERE deleted_at IS NULL`
+
+	db.Log.Debug("executing query", "query", query)
+
+	if err := db.Conn.QueryRow(ctx, query).Scan(&minID, &maxID); err != nil {
+		db.Log.Error("failed to fetch id range", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	if minID == nil || maxID == nil {
+		return nil, nil
+	}
+
+	span := *maxID - *minID + 1
+	picked := make([]models.Quote, 0, count)
+	seen := make(map[int]bool, count)
+	for round := 0; round < probeRounds && len(picked) < count; round++ {
+		candidates := make([]int, max(probeBatch, 2*count))
+		for i := range candidates {
+			candidates[i] = *minID + rng.IntN(span)
+		}
+
+		found, err := db.quotesByIDs(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+
+		// Taking hits in draw order keeps the choice uniform over the
+		// existing ids.
+		for _, id := range candidates {
+			if quote, ok := found[id]; ok && !seen[id] && len(picked) < count {
+				seen[id] = true
+				picked = append(picked, quote)
+			}
+		}
+	}
+	if len(picked) == count {
+		return picked, nil
+	}
+
+	db.Log.Debug("ids are too sparse for probing, sampling the id list", "min_id", *minID, "max_id", *maxID)
+	return db.sampleFiltered(ctx, liveQuotes(), rng, count, false)
+}
+
+// sampleFiltered lists the ids matching b and draws count of them. Listing
+// ids is an index scan over the matching rows only, so it stays cheap for
+// selective filters.
+func (db *DB) sampleFiltered(ctx context.Context, b *queryBuilder, rng *rand.Rand, count int, weighted bool) ([]models.Quote, error) {
+	ids, err := db.drawIDs(ctx, b, rng, count, weighted)
+	if err != nil {
+		return nil, err
+	}
+	return db.fetchSample(ctx, ids)
+}
+
+// drawIDs draws up to count distinct ids among the quotes matching b,
+// uniformly or proportionally to their weight.
+func (db *DB) drawIDs(ctx context.Context, b *queryBuilder, rng *rand.Rand, count int, weighted bool) ([]int, error) {
+	if !weighted {
+		ids, err := db.candidateIDs(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		return sampleIDs(rng, ids, count), nil
+	}
+
+	ids, weights, err := db.weightedCandidates(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	return sampleWeighted(rng, ids, weights, count), nil
+}
+
+// candidateIDs lists the ids matching b in id order, so that seeded draws
+// over them are reproducible.
+func (db *DB) candidateIDs(ctx context.Context, b *queryBuilder) ([]int, error) {
+	query := "SELECT id" + b.fromSQL() + b.whereSQL() + " ORDER BY id"
+
+	db.Log.Debug("executing query", "query", query, "args", b.args)
+
+	rows, err := db.Conn.Query(ctx, query, b.args...)
+	if err != nil {
+		db.Log.Error("failed to fetch candidate ids", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		db.Log.Error("failed to scan candidate ids", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	return ids, nil
+}
+
+// weightedCandidates lists the ids and weights of the quotes matching b that
+// can be drawn, in id order. The scan is served by the partial (id, weight)
+// index without touching the quote texts. It is only used for filtered
+// draws, the whole table is drawn from weightIndex.
+func (db *DB) weightedCandidates(ctx context.Context, b *queryBuilder) ([]int, []float64, error) {
+	b = b.clone()
+	b.addWhere("weight > 0")
+	query := "SELECT id, weight" + b.fromSQL() + b.whereSQL() + " ORDER BY id"
+
+	db.Log.Debug("executing query", "query", query, "args", b.args)
+
+	rows, err := db.Conn.Query(ctx, query, b.args...)
+	if err != nil {
+		db.Log.Error("failed to fetch weighted candidates", "error", err)
+		return nil, nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	defer rows.Close()
+
+	var (
+		ids     []int
+		weights []float64
+	)
+	for rows.Next() {
+		var (
+			id     int
+			weight float64
+		)
+		if err := rows.Scan(&id, &weight); err != nil {
+			db.Log.Error("failed to scan weighted candidate", "error", err)
+			return nil, nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		ids = append(ids, id)
+		weights = append(weights, weight)
+	}
+
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return nil, nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	return ids, weights, nil
+}
+
+func (db *DB) randomByIDIndex(ctx context.Context, rng *rand.Rand, count int) ([]models.Quote, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		ids, err := db.ids.get(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+
+		sample := sampleIDs(rng, ids, count)
+		quotes, err := db.fetchSample(ctx, sample)
+		if err != nil {
+			return nil, err
+		}
+		if len(quotes) == len(sample) {
+			return quotes, nil
+		}
+		// Some ids were deleted by another replica since the index was loaded.
+		db.ids.invalidate()
+	}
+	return nil, nil
+}
+
+func (db *DB) randomByWeightIndex(ctx context.Context, rng *rand.Rand, count int) ([]models.Quote, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		sample, err := db.weights.draw(ctx, db, rng, count)
+		if err != nil {
+			return nil, err
+		}
+
+		quotes, err := db.fetchSample(ctx, sample)
+		if err != nil {
+			return nil, err
+		}
+		if len(quotes) == len(sample) {
+			return quotes, nil
+		}
+		// Some ids were deleted by another replica since the index was loaded.
+		db.weights.invalidate()
+	}
+	return nil, nil
+}
+
+func (db *DB) randomByOrder(ctx context.Context, b *queryBuilder, count int) ([]models.Quote, error) {
+	query := "SELECT id, quote, author, weight, author_id, source, attribution_status, " + tagsOf("quotes.id") + ", created_at, updated_at" + b.fromSQL() + b.whereSQL() + " ORDER BY RANDOM() LIMIT " + b.arg(count)
+
+	db.Log.Debug("executing query", "query", query, "args", b.args)
+
+	rows, err := db.Conn.Query(ctx, query, b.args...)
+	if err != nil {
+		db.Log.Error("failed to fetch random quotes", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	quotes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Quote, error) {
+		var q models.Quote
+		err := row.Scan(&q.ID, &q.Quote, &q.Author, &q.Weight, &q.AuthorID, &q.Source, &q.AttributionStatus, &q.Tags, &q.CreatedAt, &q.UpdatedAt)
+		return q, err
+	})
+	if err != nil {
+		db.Log.Error("failed to scan random quotes", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	return quotes, nil
+}
+
+// sampleWeighted draws count distinct ids (or all of them when there are
+// fewer) without replacement, each with a probability proportional to its
+// weight. It keeps the count largest keys log(u)/weight (Efraimidis and
+// Spirakis), which takes a single pass over the candidates.
+func sampleWeighted(rng *rand.Rand, ids []int, weights []float64, count int) []int {
+	type keyed struct {
+		id  int
+		key float64
+	}
+	// top is ordered by descending key.
+	top := make([]keyed, 0, min(count, len(ids)))
+	for i, id := range ids {
+		key := math.Log(1-rng.Float64()) / weights[i]
+		if len(top) == count && key <= top[len(top)-1].key {
+			continue
+		}
+		pos := len(top)
+		for pos > 0 && top[pos-1].key < key {
+			pos--
+		}
+		if len(top) < count {
+			top = append(top, keyed{})
+		}
+		copy(top[pos+1:], top[pos:len(top)-1])
+		top[pos] = keyed{id: id, key: key}
+	}
+
+	picked := make([]int, len(top))
+	for i, k := range top {
+		picked[i] = k.id
+	}
+	return picked
+}
+
+// newRand returns a generator derived from seed, or a randomly seeded one
+// when seed is empty.
+func newRand(seed string) *rand.Rand {
+	if seed == "" {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	sum := sha256.Sum256([]byte(seed))
+	return rand.New(rand.NewPCG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16])))
+}
+
+// sampleIDs draws count distinct ids (or all of them when there are fewer)
+// with a partial Fisher-Yates shuffle of a copy of ids.
+func sampleIDs(rng *rand.Rand, ids []int, count int) []int {
+	pool := append([]int(nil), ids...)
+	count = min(count, len(pool))
+	for i := 0; i < count; i++ {
+		j := i + rng.IntN(len(pool)-i)
+		pool[i], pool[j] = pool[j], pool[i]
+	}
+	return pool[:count]
+}
+
+// fetchSample loads the quotes with the given ids, keeping the order of ids
+// and skipping the ones that no longer exist.
+func (db *DB) fetchSample(ctx context.Context, ids []int) ([]models.Quote, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	found, err := db.quotesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make([]models.Quote, 0, len(ids))
+	for _, id := range ids {
+		if quote, ok := found[id]; ok {
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes, nil
+}
+
+// quotesByIDs fetches the live quotes among ids, keyed by id.
+func (db *DB) quotesByIDs(ctx context.Context, ids []int) (map[int]models.Quote, error) {
+	query := `
+		SELECT id, quote, author, weight, author_id, source, attribution_status, ` + tagsOf("quotes.id") + `, created_at, updated_at
+		FROM quotes
+		WHERE id = ANY($1) AND deleted_at IS NULL
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "ids", ids)
+
+	rows, err := db.Conn.Query(ctx, query, ids)
+	if err != nil {
+		db.Log.Error("failed to fetch quotes by id", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	defer rows.Close()
+
+	found := make(map[int]models.Quote, len(ids))
+	for rows.Next() {
+		var q models.Quote
+		if err := rows.Scan(&q.ID, &q.Quote, &q.Author, &q.Weight, &q.AuthorID, &q.Source, &q.AttributionStatus, &q.Tags, &q.CreatedAt, &q.UpdatedAt); err != nil {
+			db.Log.Error("failed to scan quote row", "error", err)
+			return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		found[q.ID] = q
+	}
+
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	return found, nil
+}
+
+// idIndex is the in-memory list of quote ids used by RandomIDIndex.
+type idIndex struct {
+	mu       sync.Mutex
+	ids      []int
+	loadedAt time.Time
+}
+
+// get returns the cached ids, reloading them when they are missing or stale.
+func (x *idIndex) get(ctx context.Context, db *DB) ([]int, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.ids != nil && time.Since(x.loadedAt) < idIndexTTL {
+		return x.ids, nil
+	}
+
+	query := `SELECT id FROM quotes WHERE deleted_at IS NULL ORDER BY id`
+	db.Log.Debug("loading id index", "query", query)
+
+	rows, err := db.Conn.Query(ctx, query)
+	if err != nil {
+		db.Log.Error("failed to load id index", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		db.Log.Error("failed to scan id index", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	if ids == nil {
+		// An empty table is a valid, cacheable state.
+		ids = []int{}
+	}
+	x.ids = ids
+	x.loadedAt = time.Now()
+	return x.ids, nil
+}
+
+// invalidate makes the next get reload the ids.
+func (x *idIndex) invalidate() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.ids = nil
+}
+
+// weightIndex is the in-memory list of the ids of the quotes that can be
+// drawn in weighted mode with their cumulative weights, so that weighted
+// draws over the whole table don't scan it. Like idIndex it is rebuilt after
+// writes through this DB and at least every idIndexTTL.
+type weightIndex struct {
+	mu         sync.Mutex
+	ids        []int
+	cumulative []float64
+	loadedAt   time.Time
+}
+
+// draw picks up to count distinct ids, each with a probability proportional
+// to its weight among the ids not picked yet. Every pick is a binary search
+// over the cumulative weights and picks of an id already taken are drawn
+// again. When a few heavy quotes make that take too long, it falls back to
+// a pass over all the weights.
+func (x *weightIndex) draw(ctx context.Context, db *DB, rng *rand.Rand, count int) ([]int, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if err := x.load(ctx, db); err != nil {
+		return nil, err
+	}
+
+	count = min(count, len(x.ids))
+	if count == 0 {
+		return nil, nil
+	}
+	total := x.cumulative[len(x.cumulative)-1]
+
+	picked := make([]int, 0, count)
+	seen := make(map[int]bool, count)
+	for draws := 0; len(picked) < count && draws < probeRounds*probeBatch*count; draws++ {
+		target := rng.Float64() * total
+		i := min(sort.Search(len(x.cumulative), func(i int) bool { return x.cumulative[i] > target }), len(x.ids)-1)
+		if !seen[i] {
+			seen[i] = true
+			picked = append(picked, x.ids[i])
+		}
+	}
+	if len(picked) == count {
+		return picked, nil
+	}
+
+	weights := make([]float64, len(x.cumulative))
+	prev := 0.0
+	for i, c := range x.cumulative {
+		weights[i], prev = c-prev, c
+	}
+	return sampleWeighted(rng, x.ids, weights, count), nil
+}
+
+// load reloads the index when it is missing or stale, x.mu must be held.
+func (x *weightIndex) load(ctx context.Context, db *DB) error {
+	if x.ids != nil && time.Since(x.loadedAt) < idIndexTTL {
+		return nil
+	}
+
+	query := `SELECT id, weight FROM quotes WHERE deleted_at IS NULL AND weight > 0 ORDER BY id`
+	db.Log.Debug("loading weight index", "query", query)
+
+	rows, err := db.Conn.Query(ctx, query)
+	if err != nil {
+		db.Log.Error("failed to load weight index", "error", err)
+		return fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	defer rows.Close()
+
+	// An empty table is a valid, cacheable state.
+	ids, cumulative := []int{}, []float64{}
+	total := 0.0
+	for rows.Next() {
+		var (
+			id     int
+			weight float64
+		)
+		if err := rows.Scan(&id, &weight); err != nil {
+			db.Log.Error("failed to scan weight index", "error", err)
+			return fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		total += weight
+		ids = append(ids, id)
+		cumulative = append(cumulative, total)
+	}
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	x.ids, x.cumulative = ids, cumulative
+	x.loadedAt = time.Now()
+	return nil
+}
+
+// invalidate makes the next draw reload the weights.
+func (x *weightIndex) invalidate() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.ids, x.cumulative = nil, nil
+}
@@ -0,0 +1,71 @@
+package repositories_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+)
+
+// benchQuotes is the number of quotes the benchmark table is filled up to.
+const benchQuotes = 200_000
+
+// BenchmarkGetRandomQuotes compares the random selection strategies against a
+// real database. It needs a disposable PostgreSQL database, which is migrated
+// and filled with generated quotes:
+//
+//	QUOTEMANAGER_BENCH_DSN="host=localhost user=postgres password=postgres dbname=bench sslmode=disable" \
+//	    go test -run '^$' -bench GetRandomQuotes ./internal/repositories/
+func BenchmarkGetRandomQuotes(b *testing.B) {
+	dsn := os.Getenv("QUOTEMANAGER_BENCH_DSN")
+	if dsn == "" {
+		b.Skip("QUOTEMANAGER_BENCH_DSN is not set")
+	}
+
+	ctx := context.Background()
+
+	db, err := repositories.New(newTestLogger(), dsn)
+	require.NoError(b, err)
+	defer db.Conn.Close()
+	require.NoError(b, db.Migrate())
+
+	_, err = db.Conn.Exec(ctx, `
+		INSERT INTO authors (name)
+		SELECT 'Author ' || g FROM generate_series(0, 999) AS g
+		ON CONFLICT ((lower(name))) DO NOTHING
+	`)
+	require.NoError(b, err)
+	_, err = db.Conn.Exec(ctx, `
+		INSERT INTO quotes (author, author_id, quote)
+		SELECT authors.name, authors.id, 'Generated quote number ' || g
+		FROM generate_series(1, GREATEST(0, $1 - (SELECT count(*) FROM quotes))) AS g
+		JOIN authors ON authors.name = 'Author ' || (g % 1000)
+	`, benchQuotes)
+	require.NoError(b, err)
+
+	strategies := []repositories.RandomStrategy{
+		repositories.RandomProbe,
+		repositories.RandomIDIndex,
+		repositories.RandomOrderBy,
+	}
+
+	for _, strategy := range strategies {
+		b.Run(string(strategy), func(b *testing.B) {
+			db.RandomStrategy = strategy
+			// Warm up so RandomIDIndex doesn't count loading the index.
+			_, err := db.GetRandomQuotes(ctx, models.RandomOptions{})
+			require.NoError(b, err)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := db.GetRandomQuotes(ctx, models.RandomOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
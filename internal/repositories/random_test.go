@@ -0,0 +1,308 @@
+package repositories_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func TestDB_GetRandomQuotes(t *testing.T) {
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := newTestLogger()
+
+	type args struct {
+		ctx      context.Context
+		strategy repositories.RandomStrategy
+		opts     models.RandomOptions
+	}
+
+	quoteRows := func() *pgxmock.Rows {
+		return pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+			AddRow(1, "Random Quote", "Random Author", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+	}
+	randomQuote := models.Quote{ID: 1, Quote: "Random Quote", Author: "Random Author", Tags: []string{}, AttributionStatus: models.AttributionUnknown}
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		args         args
+		expected     []models.Quote
+		wantErr      bool
+		expectedErr  error
+	}{
+		{
+			name: "OK - Probe",
+			args: args{ctx: context.Background()},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT min\(id\), max\(id\) FROM quotes`).
+					WillReturnRows(pgxmock.NewRows([]string{"min", "max"}).AddRow(ptr(1), ptr(1)))
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`).
+					WithArgs(pgxmock.AnyArg()).
+					WillReturnRows(quoteRows())
+			},
+			expected: []models.Quote{randomQuote},
+		},
+		{
+			name: "OK - Probe falls back to the id list on sparse ids",
+			args: args{ctx: context.Background(), strategy: repositories.RandomProbe},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT min\(id\), max\(id\) FROM quotes`).
+					WillReturnRows(pgxmock.NewRows([]string{"min", "max"}).AddRow(ptr(1), ptr(1<<40)))
+				for i := 0; i < 4; i++ {
+					mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`).
+						WithArgs(pgxmock.AnyArg()).
+						WillReturnRows(pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}))
+				}
+				mock.ExpectQuery(`SELECT id FROM quotes WHERE deleted_at IS NULL ORDER BY id`).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`).
+					WithArgs([]int{1}).
+					WillReturnRows(quoteRows())
+			},
+			expected: []models.Quote{randomQuote},
+		},
+		{
+			name: "OK - ID index",
+			args: args{ctx: context.Background(), strategy: repositories.RandomIDIndex},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT id FROM quotes WHERE deleted_at IS NULL ORDER BY id`).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`).
+					WithArgs([]int{1}).
+					WillReturnRows(quoteRows())
+			},
+			expected: []models.Quote{randomQuote},
+		},
+		{
+			name: "OK - Order by",
+			args: args{ctx: context.Background(), strategy: repositories.RandomOrderBy},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL ORDER BY RANDOM\(\) LIMIT \$1`).
+					WithArgs(1).
+					WillReturnRows(quoteRows())
+			},
+			expected: []models.Quote{randomQuote},
+		},
+		{
+			name: "OK - Filtered, more requested than available",
+			args: args{
+				ctx: context.Background(),
+				opts: models.RandomOptions{
+					Filter: models.QuoteFilter{Author: "Seneca", MaxLength: 80},
+					Count:  3,
+				},
+			},
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM quotes WHERE deleted_at IS NULL AND author_id IN (SELECT id FROM authors WHERE lower(name) = lower($1) AND name = $1 UNION ALL SELECT author_id FROM author_aliases WHERE lower(alias) = lower($1) AND alias = $1) AND char_length(quote) <= $2 ORDER BY id`)).
+					WithArgs("Seneca", 80).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`).
+					WithArgs([]int{1}).
+					WillReturnRows(quoteRows())
+			},
+			expected: []models.Quote{randomQuote},
+		},
+		{
+			name: "OK - Seeded order by samples the id list",
+			args: args{
+				ctx:      context.Background(),
+				strategy: repositories.RandomOrderBy,
+				opts:     models.RandomOptions{Seed: "abc"},
+			},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT id FROM quotes WHERE deleted_at IS NULL ORDER BY id`).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`).
+					WithArgs([]int{1}).
+					WillReturnRows(quoteRows())
+			},
+			expected: []models.Quote{randomQuote},
+		},
+		{
+			name: "OK - Weighted",
+			args: args{
+				ctx: context.Background(),
+				opts: models.RandomOptions{
+					Filter: models.QuoteFilter{Author: "Seneca"},
+					Count:  3,
+					Mode:   models.RandomWeighted,
+				},
+			},
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, weight FROM quotes WHERE deleted_at IS NULL AND author_id IN (SELECT id FROM authors WHERE lower(name) = lower($1) AND name = $1 UNION ALL SELECT author_id FROM author_aliases WHERE lower(alias) = lower($1) AND alias = $1) AND weight > 0 ORDER BY id`)).
+					WithArgs("Seneca").
+					WillReturnRows(pgxmock.NewRows([]string{"id", "weight"}).AddRow(1, 2.5))
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`).
+					WithArgs([]int{1}).
+					WillReturnRows(quoteRows())
+			},
+			expected: []models.Quote{randomQuote},
+		},
+		{
+			name: "Unknown mode",
+			args: args{
+				ctx:  context.Background(),
+				opts: models.RandomOptions{Mode: "loaded"},
+			},
+			mockBehavior: func() {},
+			wantErr:      true,
+			expectedErr:  repositories.ErrUnknownRandomMode,
+		},
+		{
+			name: "No Rows - ErrQuoteNotFound",
+			args: args{ctx: context.Background()},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT min\(id\), max\(id\) FROM quotes`).
+					WillReturnRows(pgxmock.NewRows([]string{"min", "max"}).AddRow(nil, nil))
+			},
+			wantErr:     true,
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+		{
+			name: "No Rows matching the filter - ErrQuoteNotFound",
+			args: args{
+				ctx:  context.Background(),
+				opts: models.RandomOptions{Filter: models.QuoteFilter{Author: "Nobody"}},
+			},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT id FROM quotes WHERE deleted_at IS NULL AND author_id IN \(SELECT id FROM authors WHERE lower\(name\) = lower\(\$1\) AND name = \$1 UNION ALL SELECT author_id FROM author_aliases WHERE lower\(alias\) = lower\(\$1\) AND alias = \$1\) ORDER BY id`).
+					WithArgs("Nobody").
+					WillReturnRows(pgxmock.NewRows([]string{"id"}))
+			},
+			wantErr:     true,
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+		{
+			name: "DB Error",
+			args: args{ctx: context.Background()},
+			mockBehavior: func() {
+				mock.ExpectQuery(`SELECT min\(id\), max\(id\) FROM quotes`).
+					WillReturnError(errors.ErrQuery)
+			},
+			wantErr:     true,
+			expectedErr: errors.ErrQuery,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			r := &repositories.DB{
+				Log:            logger,
+				Conn:           mock,
+				RandomStrategy: testCase.args.strategy,
+			}
+
+			actualQuotes, actualErr := r.GetRandomQuotes(testCase.args.ctx, testCase.args.opts)
+
+			if testCase.wantErr {
+				assert.Error(t, actualErr, "Expected an error")
+				if testCase.expectedErr != nil {
+					assert.ErrorIs(t, actualErr, testCase.expectedErr)
+				}
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actualQuotes, "Quote data mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_GetRandomQuotes_Seeded(t *testing.T) {
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := newTestLogger()
+
+	draw := func(seed string) []models.Quote {
+		t.Helper()
+		ids := pgxmock.NewRows([]string{"id"})
+		quotes := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"})
+		for id := 1; id <= 100; id++ {
+			ids.AddRow(id)
+			quotes.AddRow(id, fmt.Sprintf("Quote %d", id), "Author", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+		}
+		mock.ExpectQuery(`SELECT id FROM quotes WHERE deleted_at IS NULL ORDER BY id`).WillReturnRows(ids)
+		mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnRows(quotes)
+
+		// A fresh DB loads its own id index, so every draw runs the same queries.
+		r := &repositories.DB{Log: logger, Conn: mock, RandomStrategy: repositories.RandomIDIndex}
+		picked, err := r.GetRandomQuotes(context.Background(), models.RandomOptions{Count: 5, Seed: seed})
+		require.NoError(t, err)
+		require.Len(t, picked, 5)
+		return picked
+	}
+
+	first := draw("abc")
+	assert.Equal(t, first, draw("abc"), "same seed must draw the same quotes")
+	assert.NotEqual(t, first, draw("another seed"), "different seeds should draw different quotes")
+	assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+}
+
+func TestDB_GetRandomQuotes_WeightIndex(t *testing.T) {
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer mock.Close()
+
+	const (
+		indexQuery = `SELECT id, weight FROM quotes WHERE deleted_at IS NULL AND weight > 0 ORDER BY id`
+		fetchQuery = `SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = ANY\(\$1\)`
+	)
+	quoteRows := func(ids ...int) *pgxmock.Rows {
+		rows := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"})
+		for _, id := range ids {
+			rows.AddRow(id, fmt.Sprintf("Quote %d", id), "Author", 1.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+		}
+		return rows
+	}
+	opts := models.RandomOptions{Count: 2, Mode: models.RandomWeighted, Seed: "abc"}
+
+	r := &repositories.DB{Log: newTestLogger(), Conn: mock}
+
+	// A heavy quote makes repeated picks likely, the draw still returns
+	// distinct quotes.
+	mock.ExpectQuery(regexp.QuoteMeta(indexQuery)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "weight"}).AddRow(1, 1000.0).AddRow(2, 0.001))
+	mock.ExpectQuery(fetchQuery).WithArgs([]int{1, 2}).WillReturnRows(quoteRows(1, 2))
+
+	quotes, err := r.GetRandomQuotes(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, quotes, 2)
+	assert.Equal(t, 1, quotes[0].ID)
+	assert.Equal(t, 2, quotes[1].ID)
+
+	// The weights are cached, the next draw only fetches the quotes.
+	mock.ExpectQuery(fetchQuery).WithArgs([]int{1, 2}).WillReturnRows(quoteRows(1, 2))
+
+	_, err = r.GetRandomQuotes(context.Background(), opts)
+	require.NoError(t, err)
+
+	// A quote deleted by another replica reloads the weights.
+	mock.ExpectQuery(fetchQuery).WithArgs([]int{1, 2}).WillReturnRows(quoteRows(1))
+	mock.ExpectQuery(regexp.QuoteMeta(indexQuery)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "weight"}).AddRow(1, 1000.0))
+	mock.ExpectQuery(fetchQuery).WithArgs([]int{1}).WillReturnRows(quoteRows(1))
+
+	quotes, err = r.GetRandomQuotes(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, quotes, 1)
+	assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+}
@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// revisionInsert records the rows of the WITH item q, which must return id,
+// quote, author and weight, as revisions made by operation. Writing the quote
+// and its revision in one statement keeps the history complete without a
+// transaction.
+func revisionInsert(operation models.RevisionOperation) string {
+	return `
+		INSERT INTO quote_revisions (quote_id, operation, author, quote, weight)
+		SELECT id, '` + string(operation) + `', author, quote, weight FROM q
+	`
+}
+
+// revisionItem is revisionInsert as a WITH item named rev.
+func revisionItem(operation models.RevisionOperation) string {
+	return "rev AS (" + revisionInsert(operation) + ")"
+}
+
+// changedRevisionItem is revisionItem for writes that lock the quote with
+// lockLiveQuote. Revisions only track the author, quote and weight, so no
+// revision is recorded when the write left all three unchanged.
+func changedRevisionItem(operation models.RevisionOperation) string {
+	return `rev AS (
+		INSERT INTO quote_revisions (quote_id, operation, author, quote, weight)
+		SELECT q.id, '` + string(operation) + `', q.author, q.quote, q.weight FROM q, live
+		WHERE (q.author, q.quote, q.weight) IS DISTINCT FROM (live.author, live.quote, live.weight)
+	)`
+}
+
+// numberedRevisions numbers the revisions of the quote $1 from 1.
+const numberedRevisions = `
+	SELECT row_number() OVER (ORDER BY id) AS rev, operation, author, quote, weight, created_at
+	FROM quote_revisions
+	WHERE quote_id = $1
+`
+
+// GetQuoteHistory returns the revisions of a quote, trashed or not, oldest
+// first, each with the author and quote changes made since the previous one.
+func (db *DB) GetQuoteHistory(ctx context.Context, quoteID int) ([]models.QuoteRevision, error) {
+	db.Log.Debug("started getting quote history DB", "quote_id", quoteID)
+
+	query := numberedRevisions + ` ORDER BY rev`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "quote_id", quoteID)
+
+	rows, err := db.Conn.Query(ctx, query, quoteID)
+	if err != nil {
+		db.Log.Error("failed to fetch quote history", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	defer rows.Close()
+
+	var revisions []models.QuoteRevision
+	for rows.Next() {
+		var r models.QuoteRevision
+		if err := rows.Scan(&r.Rev, &r.Operation, &r.Author, &r.Quote, &r.Weight, &r.CreatedAt); err != nil {
+			db.Log.Error("failed to scan quote revision", "error", err)
+			return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		if len(revisions) > 0 {
+			r.Changes = revisionChanges(revisions[len(revisions)-1], r)
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	if len(revisions) == 0 {
+		db.Log.Warn("no quote was found with the given id", "id", quoteID)
+		return nil, errors.ErrQuoteNotFound
+	}
+
+	db.Log.Debug("ended getting quote history DB", "count", len(revisions))
+	return revisions, nil
+}
+
+// revisionChanges lists the author and quote changes between two revisions.
+func revisionChanges(prev, next models.QuoteRevision) []models.FieldChange {
+	var changes []models.FieldChange
+	if prev.Author != next.Author {
+		changes = append(changes, models.FieldChange{Field: "author", From: prev.Author, To: next.Author})
+	}
+	if prev.Quote != next.Quote {
+		changes = append(changes, models.FieldChange{Field: "quote", From: prev.Quote, To: next.Quote})
+	}
+	return changes
+}
+
+// RevertQuote restores the author, quote and weight of revision rev of a
+// live quote. The revert is recorded as a new revision. It fails with
+// ErrQuoteNotFound when the quote is missing or in the trash and with
+// ErrRevisionNotFound when it has no revision rev.
+func (db *DB) RevertQuote(ctx context.Context, quoteID, rev int) (models.Quote, error) {
+	db.Log.Debug("started reverting quote DB", "quote_id", quoteID, "rev", rev)
+	var reverted models.Quote
+
+	query := `
+		WITH target AS (
+			SELECT author, quote, weight
+			FROM (` + numberedRevisions + `) AS revisions
+			WHERE rev = $2
+		), ` + lockLiveQuote("$1") + `, ` + resolveAuthor("SELECT author FROM target", liveGuard) + `, q AS (
+			UPDATE quotes
+			SET author = resolved.name, author_id = resolved.id,
+				quote = target.quote, weight = target.weight, updated_at = now()
+			FROM target, resolved
+			WHERE quotes.id = $1 AND quotes.deleted_at IS NULL
+			RETURNING quotes.id, quotes.quote, quotes.author, quotes.weight, quotes.author_id, quotes.source, quotes.attribution_status, quotes.created_at, quotes.updated_at
+		), ` + revisionItem(models.RevisionRevert) + `
+		SELECT id, quote, author, weight, author_id, source, attribution_status, ` + tagsOf("q.id") + `, created_at, updated_at FROM q
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "quote_id", quoteID, "rev", rev)
+
+	err := db.Conn.QueryRow(ctx, query, quoteID, rev).Scan(
+		&reverted.ID,
+		&reverted.Quote,
+		&reverted.Author,
+		&reverted.Weight,
+		&reverted.AuthorID,
+		&reverted.Source,
+		&reverted.AttributionStatus,
+		&reverted.Tags,
+		&reverted.CreatedAt,
+		&reverted.UpdatedAt,
+	)
+
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return models.Quote{}, db.revertNotFound(ctx, quoteID, rev)
+		}
+		db.Log.Error("failed to revert quote", "error", err)
+		return models.Quote{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.weights.invalidate()
+	db.Log.Debug("Finished reverting quote DB", "quote_id", reverted.ID)
+	return reverted, nil
+}
+
+// revertNotFound tells why RevertQuote found nothing to revert: the quote is
+// missing or in the trash, or it has no revision rev.
+func (db *DB) revertNotFound(ctx context.Context, quoteID, rev int) error {
+	query := `SELECT EXISTS (SELECT 1 FROM quotes WHERE id = $1 AND ` + liveCondition + `)`
+
+	db.Log.Debug("executing query", "query", query, "quote_id", quoteID)
+
+	var live bool
+	if err := db.Conn.QueryRow(ctx, query, quoteID).Scan(&live); err != nil {
+		db.Log.Error("failed to check quote", "error", err)
+		return fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	if !live {
+		db.Log.Warn("no live quote was found with the given id", "id", quoteID)
+		return errors.ErrQuoteNotFound
+	}
+	db.Log.Warn("no revision of the quote was found", "id", quoteID, "rev", rev)
+	return errors.ErrRevisionNotFound
+}
@@ -0,0 +1,173 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func TestDB_GetQuoteHistory(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const query = `SELECT row_number() OVER (ORDER BY id) AS rev, operation, author, quote, weight, created_at FROM quote_revisions WHERE quote_id = $1 ORDER BY rev`
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	deleted := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     []models.QuoteRevision
+		expectedErr  error
+	}{
+		{
+			name: "OK - With changes",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"rev", "operation", "author", "quote", "weight", "created_at"}).
+						AddRow(1, models.RevisionCreate, "Confucius", "Old", 1.0, created).
+						AddRow(2, models.RevisionUpdate, "Kong Fuzi", "New", 1.0, updated).
+						AddRow(3, models.RevisionDelete, "Kong Fuzi", "New", 1.0, deleted))
+			},
+			expected: []models.QuoteRevision{
+				{Rev: 1, Operation: models.RevisionCreate, Author: "Confucius", Quote: "Old", Weight: 1, CreatedAt: created},
+				{
+					Rev: 2, Operation: models.RevisionUpdate, Author: "Kong Fuzi", Quote: "New", Weight: 1, CreatedAt: updated,
+					Changes: []models.FieldChange{
+						{Field: "author", From: "Confucius", To: "Kong Fuzi"},
+						{Field: "quote", From: "Old", To: "New"},
+					},
+				},
+				{Rev: 3, Operation: models.RevisionDelete, Author: "Kong Fuzi", Quote: "New", Weight: 1, CreatedAt: deleted},
+			},
+		},
+		{
+			name: "No revisions - ErrQuoteNotFound",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"rev", "operation", "author", "quote", "weight", "created_at"}))
+			},
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+		{
+			name: "DB Error",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnError(errors.ErrQuery)
+			},
+			expectedErr: errors.ErrQuery,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.GetQuoteHistory(context.Background(), 1)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "History mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_RevertQuote(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		query     = `UPDATE quotes SET author = resolved.name, author_id = resolved.id, quote = target.quote, weight = target.weight, updated_at = now() FROM target, resolved WHERE quotes.id = $1 AND quotes.deleted_at IS NULL`
+		liveQuery = `SELECT EXISTS (SELECT 1 FROM quotes WHERE id = $1 AND deleted_at IS NULL)`
+	)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     models.Quote
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1, 2).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+						AddRow(1, "Old", "Confucius", 1.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}))
+			},
+			expected: models.Quote{ID: 1, Quote: "Old", Author: "Confucius", Weight: 1, Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+		},
+		{
+			name: "Unknown revision - ErrRevisionNotFound",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(1, 2).
+					WillReturnError(pgx.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(liveQuery)).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			expectedErr: errors.ErrRevisionNotFound,
+		},
+		{
+			name: "Trashed quote - ErrQuoteNotFound",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(`live AS (SELECT id, author, quote, weight FROM quotes WHERE id = $1 AND deleted_at IS NULL FOR UPDATE)`)+`.*`+
+					regexp.QuoteMeta(`NOT EXISTS (SELECT 1 FROM alias_match) AND EXISTS (SELECT 1 FROM live)`)+`.*`+regexp.QuoteMeta(query)).
+					WithArgs(1, 2).
+					WillReturnError(pgx.ErrNoRows)
+				mock.ExpectQuery(regexp.QuoteMeta(liveQuery)).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.RevertQuote(context.Background(), 1, 2)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Quote data mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
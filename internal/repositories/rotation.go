@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stdErrors "errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+const defaultRotationTTL = 24 * time.Hour
+
+// randomRotation draws count quotes matching b that were not served to
+// client yet. A rotation is a shuffled list of the matching ids that requests
+// serve in order, so only starting a rotation lists the ids. When it runs out
+// a new rotation starts, leaving out the quotes of the current response.
+// Quotes deleted since the rotation started are skipped, quotes added join
+// the next rotation.
+func (db *DB) randomRotation(ctx context.Context, b *queryBuilder, rng *rand.Rand, count int, client string, weighted bool) ([]models.Quote, error) {
+	pool := rotationPool(b)
+
+	picked, err := db.advanceRotation(ctx, client, pool, count)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(picked) < count {
+		db.Log.Debug("rotation exhausted, starting a new one", "client", client, "picked", len(picked))
+
+		ids, err := db.rotationOrder(ctx, b, rng, weighted)
+		if err != nil {
+			return nil, err
+		}
+
+		// The quotes of this response go last, so the new rotation does not
+		// serve them again right away.
+		fresh := make([]int, 0, len(ids))
+		var repeated []int
+		for _, id := range ids {
+			if slices.Contains(picked, id) {
+				repeated = append(repeated, id)
+			} else {
+				fresh = append(fresh, id)
+			}
+		}
+		served := min(count-len(picked), len(fresh))
+		picked = append(picked, fresh[:served]...)
+
+		if len(ids) > 0 {
+			if err := db.saveRotation(ctx, client, pool, append(fresh, repeated...), served); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return db.fetchSample(ctx, picked)
+}
+
+// rotationOrder lists the ids matching b in the order a new rotation serves
+// them: shuffled uniformly, or in a weighted random order where heavier
+// quotes tend to come first.
+func (db *DB) rotationOrder(ctx context.Context, b *queryBuilder, rng *rand.Rand, weighted bool) ([]int, error) {
+	if !weighted {
+		ids, err := db.candidateIDs(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		return sampleIDs(rng, ids, len(ids)), nil
+	}
+
+	ids, weights, err := db.weightedCandidates(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	return weightedOrder(rng, ids, weights), nil
+}
+
+// weightedOrder sorts ids by the keys of sampleWeighted, so that every
+// prefix of the result is a weighted sample without replacement.
+func weightedOrder(rng *rand.Rand, ids []int, weights []float64) []int {
+	keys := make(map[int]float64, len(ids))
+	for i, id := range ids {
+		keys[id] = math.Log(1-rng.Float64()) / weights[i]
+	}
+
+	ordered := append([]int(nil), ids...)
+	sort.SliceStable(ordered, func(i, j int) bool { return keys[ordered[i]] > keys[ordered[j]] })
+	return ordered
+}
+
+// rotationPool identifies the filter of a rotation by its rendered SQL and arguments.
+func rotationPool(b *queryBuilder) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s%s%v", b.fromSQL(), b.whereSQL(), b.args))
+	return hex.EncodeToString(sum[:])
+}
+
+// advanceRotation takes the next count ids of a live rotation, fewer when it
+// runs out, and extends its expiry. The update locks the rotation row, so
+// concurrent requests of a client are served distinct ids.
+func (db *DB) advanceRotation(ctx context.Context, client, pool string, count int) ([]int, error) {
+	query := `
+		UPDATE random_rotations
+		SET served = served + $3, expires_at = now() + make_interval(secs => $4)
+		WHERE client = $1 AND pool = $2 AND expires_at > now() AND served < cardinality(ids)
+		RETURNING ids[served - $3 + 1 : served]
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "client", client)
+
+	var ids []int
+	if err := db.Conn.QueryRow(ctx, query, client, pool, count, db.rotationTTL().Seconds()).Scan(&ids); err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		db.Log.Error("failed to advance rotation", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	return ids, nil
+}
+
+// saveRotation starts a rotation over ids, served of them already served. It
+// also drops the other expired rotations, so abandoned clients do not pile up.
+// When concurrent requests both start a rotation, the last one is kept.
+func (db *DB) saveRotation(ctx context.Context, client, pool string, ids []int, served int) error {
+	query := `
+		WITH expired AS (
+			DELETE FROM random_rotations
+			WHERE expires_at <= now() AND (client, pool) <> ($1, $2)
+		)
+		INSERT INTO random_rotations (client, pool, ids, served, expires_at)
+		VALUES ($1, $2, $3, $4, now() + make_interval(secs => $5))
+		ON CONFLICT (client, pool) DO UPDATE
+		SET ids = EXCLUDED.ids, served = EXCLUDED.served, expires_at = EXCLUDED.expires_at
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "client", client, "ids", len(ids))
+
+	if _, err := db.Conn.Exec(ctx, query, client, pool, ids, served, db.rotationTTL().Seconds()); err != nil {
+		db.Log.Error("failed to save rotation", "error", err)
+		return fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+	return nil
+}
+
+// rotationTTL is how long an idle rotation is kept.
+func (db *DB) rotationTTL() time.Duration {
+	if db.RotationTTL <= 0 {
+		return defaultRotationTTL
+	}
+	return db.RotationTTL
+}
@@ -0,0 +1,149 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func TestDB_GetRandomQuotes_Rotation(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		client = "kiosk-1"
+
+		advanceQuery = `UPDATE random_rotations SET served = served + $3, expires_at = now() + make_interval(secs => $4) WHERE client = $1 AND pool = $2 AND expires_at > now() AND served < cardinality(ids) RETURNING ids[served - $3 + 1 : served]`
+		allQuery     = `SELECT id FROM quotes WHERE deleted_at IS NULL ORDER BY id`
+		fetchQuery   = `SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id ORDER BY t.slug), created_at, updated_at FROM quotes WHERE id = ANY($1)`
+		saveQuery    = `INSERT INTO random_rotations (client, pool, ids, served, expires_at)`
+	)
+
+	quoteRows := func(ids ...int) *pgxmock.Rows {
+		rows := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"})
+		for _, id := range ids {
+			rows.AddRow(id, "Quote", "Author", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+		}
+		return rows
+	}
+	idRows := func(ids ...int) *pgxmock.Rows {
+		rows := pgxmock.NewRows([]string{"id"})
+		for _, id := range ids {
+			rows.AddRow(id)
+		}
+		return rows
+	}
+
+	testTable := []struct {
+		name         string
+		count        int
+		mockBehavior func()
+		expected     []models.Quote
+		expectedErr  error
+	}{
+		{
+			name: "OK - New rotation",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(advanceQuery)).
+					WithArgs(client, pgxmock.AnyArg(), 1, 86400.0).
+					WillReturnRows(pgxmock.NewRows([]string{"ids"}))
+				mock.ExpectQuery(regexp.QuoteMeta(allQuery)).
+					WillReturnRows(idRows(2))
+				mock.ExpectExec(regexp.QuoteMeta(saveQuery)).
+					WithArgs(client, pgxmock.AnyArg(), []int{2}, 1, 86400.0).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery(regexp.QuoteMeta(fetchQuery)).
+					WithArgs([]int{2}).
+					WillReturnRows(quoteRows(2))
+			},
+			expected: []models.Quote{{ID: 2, Quote: "Quote", Author: "Author", Tags: []string{}, AttributionStatus: models.AttributionUnknown}},
+		},
+		{
+			name: "OK - Serves the next ids of the rotation",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(advanceQuery)).
+					WithArgs(client, pgxmock.AnyArg(), 1, 86400.0).
+					WillReturnRows(pgxmock.NewRows([]string{"ids"}).AddRow([]int{3}))
+				mock.ExpectQuery(regexp.QuoteMeta(fetchQuery)).
+					WithArgs([]int{3}).
+					WillReturnRows(quoteRows(3))
+			},
+			expected: []models.Quote{{ID: 3, Quote: "Quote", Author: "Author", Tags: []string{}, AttributionStatus: models.AttributionUnknown}},
+		},
+		{
+			name:  "OK - Starts a new rotation without repeating the response",
+			count: 2,
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(advanceQuery)).
+					WithArgs(client, pgxmock.AnyArg(), 2, 86400.0).
+					WillReturnRows(pgxmock.NewRows([]string{"ids"}).AddRow([]int{3}))
+				mock.ExpectQuery(regexp.QuoteMeta(allQuery)).
+					WillReturnRows(idRows(1, 3))
+				mock.ExpectExec(regexp.QuoteMeta(saveQuery)).
+					WithArgs(client, pgxmock.AnyArg(), []int{1, 3}, 1, 86400.0).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mock.ExpectQuery(regexp.QuoteMeta(fetchQuery)).
+					WithArgs([]int{3, 1}).
+					WillReturnRows(quoteRows(1, 3))
+			},
+			expected: []models.Quote{
+				{ID: 3, Quote: "Quote", Author: "Author", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+				{ID: 1, Quote: "Quote", Author: "Author", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+			},
+		},
+		{
+			name: "No quotes - ErrQuoteNotFound",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(advanceQuery)).
+					WithArgs(client, pgxmock.AnyArg(), 1, 86400.0).
+					WillReturnRows(pgxmock.NewRows([]string{"ids"}))
+				mock.ExpectQuery(regexp.QuoteMeta(allQuery)).
+					WillReturnRows(idRows())
+			},
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+		{
+			name: "DB Error",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(advanceQuery)).
+					WithArgs(client, pgxmock.AnyArg(), 1, 86400.0).
+					WillReturnError(errors.ErrQuery)
+			},
+			expectedErr: errors.ErrQuery,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.GetRandomQuotes(context.Background(), models.RandomOptions{
+				Count:  testCase.count,
+				Client: client,
+			})
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Quote data mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
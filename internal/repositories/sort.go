@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// sortKind is the Go type a sort expression is scanned into and encoded in cursors as.
+type sortKind int
+
+const (
+	sortInt sortKind = iota
+	sortFloat
+	sortText
+	sortTime
+)
+
+// sortColumn is the SQL expression behind a models.SortField. Only these
+// expressions ever reach the query text, user input is never interpolated.
+type sortColumn struct {
+	expr string
+	kind sortKind
+}
+
+var sortColumns = map[models.SortField]sortColumn{
+	models.SortByID:         {expr: "id", kind: sortInt},
+	models.SortByAuthor:     {expr: "author", kind: sortText},
+	models.SortByCreatedAt:  {expr: "created_at", kind: sortTime},
+	models.SortByLength:     {expr: "char_length(quote)", kind: sortInt},
+	models.SortByPopularity: {expr: "weight", kind: sortFloat},
+	models.SortByRank:       {expr: "rank", kind: sortFloat},
+}
+
+// ErrRankWithoutSearch is returned when ordering by relevance without a search query.
+var ErrRankWithoutSearch = errors.New(errors.CodeInvalidArgument, "sorting by rank requires a search query")
+
+// newValue returns a pointer suitable for scanning a value of this kind.
+func (k sortKind) newValue() any {
+	switch k {
+	case sortFloat:
+		return new(float64)
+	case sortText:
+		return new(string)
+	case sortTime:
+		return new(time.Time)
+	default:
+		return new(int64)
+	}
+}
+
+// deref returns the value behind a pointer created by newValue.
+func deref(v any) any {
+	switch v := v.(type) {
+	case *float64:
+		return *v
+	case *string:
+		return *v
+	case *time.Time:
+		return *v
+	case *int64:
+		return *v
+	default:
+		return v
+	}
+}
+
+// decode parses a cursor value of this kind.
+func (k sortKind) decode(raw json.RawMessage) (any, error) {
+	v := k.newValue()
+	if err := json.Unmarshal(raw, v); err != nil {
+		return nil, err
+	}
+	return deref(v), nil
+}
+
+// orderKeys returns the effective ordering: the requested keys (relevance for
+// searches, id otherwise, by default) followed by id as a tie-breaker so that
+// the order is total and therefore deterministic and usable for keyset pagination.
+func orderKeys(filters models.QuoteFilter) ([]models.SortKey, error) {
+	keys := filters.Sort
+	if len(keys) == 0 && filters.Search != "" {
+		keys = []models.SortKey{{Field: models.SortByRank, Desc: true}}
+	}
+
+	for _, k := range keys {
+		if k.Field == models.SortByRank && filters.Search == "" {
+			return nil, ErrRankWithoutSearch
+		}
+	}
+
+	for _, k := range keys {
+		if k.Field == models.SortByID {
+			return keys, nil
+		}
+	}
+	return append(append([]models.SortKey(nil), keys...), models.SortKey{Field: models.SortByID}), nil
+}
+
+// sortSignature identifies an ordering so cursors can't be reused with another one.
+func sortSignature(keys []models.SortKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// orderBySQL renders the ORDER BY clause, flipping every direction when reverse is set.
+func orderBySQL(keys []models.SortKey, reverse bool) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		dir := "ASC"
+		if k.Desc != reverse {
+			dir = "DESC"
+		}
+		parts[i] = sortColumns[k.Field].expr + " " + dir
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// addKeysetCondition restricts b to rows strictly after values in the given
+// ordering (or strictly before when reverse is set). Mixed directions rule out
+// row comparison, so the condition is spelled out as
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+func addKeysetCondition(b *queryBuilder, keys []models.SortKey, values []any, reverse bool) {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = b.arg(v)
+	}
+
+	alternatives := make([]string, len(keys))
+	for i, k := range keys {
+		if len(keys) == 1 {
+			alternatives[i] = sortColumns[k.Field].expr + " " + keysetOp(k, reverse) + " " + placeholders[i]
+			break
+		}
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, sortColumns[keys[j].Field].expr+" = "+placeholders[j])
+		}
+		terms = append(terms, sortColumns[k.Field].expr+" "+keysetOp(k, reverse)+" "+placeholders[i])
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+
+	if len(alternatives) == 1 {
+		b.addWhere(alternatives[0])
+		return
+	}
+	b.addWhere("(" + strings.Join(alternatives, " OR ") + ")")
+}
+
+func keysetOp(k models.SortKey, reverse bool) string {
+	if k.Desc != reverse {
+		return "<"
+	}
+	return ">"
+}
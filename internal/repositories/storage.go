@@ -3,10 +3,14 @@ package repositories
 import (
 	"context"
 	stdErrors "errors"
+	"fmt"
+	"html"
 	"log/slog"
 	"quotemanager/internal/models"
 	"quotemanager/pkg/errors"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -17,20 +21,81 @@ type PoolConnector interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
 	Ping(ctx context.Context) error
 	Close()
 }
 
 type DBInterface interface {
-	AddQuote(ctx context.Context, quote models.Quote) error
-	GetQuotes(ctx context.Context, filters models.QuoteFilter) ([]models.Quote, error)
-	GetRandomQuote(ctx context.Context) (models.Quote, error)
-	DeleteQuote(ctx context.Context, quoteID string) error
+	AddQuote(ctx context.Context, quote models.Quote) (models.Quote, error)
+	GetQuotes(ctx context.Context, filters models.QuoteFilter) (models.QuotePage, error)
+	GetQuote(ctx context.Context, quoteID int) (models.Quote, error)
+	GetRandomQuotes(ctx context.Context, opts models.RandomOptions) ([]models.Quote, error)
+	UpdateQuote(ctx context.Context, quote models.Quote) (models.Quote, error)
+	PatchQuote(ctx context.Context, quoteID int, patch models.QuotePatch) (models.Quote, error)
+	DeleteQuote(ctx context.Context, quoteID int) error
+	SuggestAuthors(ctx context.Context, prefix string, limit int) ([]models.AuthorSuggestion, error)
+	GetDailyQuote(ctx context.Context, tz string, date time.Time) (models.DailyQuote, error)
+	RestoreQuote(ctx context.Context, quoteID int) (models.Quote, error)
+	PurgeQuote(ctx context.Context, quoteID int) error
+	PurgeTrash(ctx context.Context, olderThan time.Duration) (int64, error)
+	GetQuoteHistory(ctx context.Context, quoteID int) ([]models.QuoteRevision, error)
+	RevertQuote(ctx context.Context, quoteID, rev int) (models.Quote, error)
+	GetTags(ctx context.Context) ([]models.Tag, error)
+	RenameTag(ctx context.Context, from, to string) (models.Tag, error)
+	MergeTags(ctx context.Context, from, into string) (models.Tag, error)
+	AddAuthor(ctx context.Context, author models.Author) (models.Author, error)
+	GetAuthors(ctx context.Context, limit, offset int) ([]models.Author, error)
+	GetAuthor(ctx context.Context, authorID int) (models.Author, error)
+	UpdateAuthor(ctx context.Context, author models.Author) (models.Author, error)
+	DeleteAuthor(ctx context.Context, authorID int) error
+	FindDuplicateAuthors(ctx context.Context, minSimilarity float64, limit int) ([]models.AuthorDuplicate, error)
+	MergeAuthors(ctx context.Context, intoID int, authorIDs []int) (models.Author, error)
+}
+
+// SQLSTATE codes of the constraint violations mapped to application errors.
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// isPgError reports whether err was raised by PostgreSQL with the SQLSTATE code.
+func isPgError(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return stdErrors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// Quotes are stored as plain text, so ts_headline marks the matches with
+// private use sentinels that are stripped from the quote beforehand. The
+// snippet is HTML escaped before the sentinels become <mark> tags, markup
+// typed into a quote is never returned as markup.
+const (
+	headlineStart = "\uE000"
+	headlineStop  = "\uE001"
+)
+
+// headlineOptions configures the ts_headline snippets of search results.
+const headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+var headlineMarks = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// headlineHTML turns a ts_headline snippet into escaped HTML with the
+// matches wrapped in <mark> tags.
+func headlineHTML(snippet string) string {
+	return headlineMarks.Replace(html.EscapeString(snippet))
 }
 
 type DB struct {
 	Log  *slog.Logger
 	Conn PoolConnector
+
+	// RandomStrategy selects how random quotes are picked, RandomProbe by default.
+	RandomStrategy RandomStrategy
+	// RotationTTL is how long an idle client rotation is kept, defaultRotationTTL by default.
+	RotationTTL time.Duration
+
+	ids     idIndex
+	weights weightIndex
 }
 
 var _ DBInterface = (*DB)(nil)
@@ -55,116 +120,371 @@ func New(log *slog.Logger, address string) (*DB, error) {
 	}, nil
 }
 
-func (db *DB) AddQuote(ctx context.Context, quote models.Quote) error {
+func (db *DB) AddQuote(ctx context.Context, quote models.Quote) (models.Quote, error) {
 
 	db.Log.Debug("started adding quote DB")
+	var created models.Quote
 
 	query := `
-        INSERT INTO quotes (author, quote)
-        VALUES ($1, $2)
+        WITH ` + resolveAuthor("$1", "") + `, q AS (
+            INSERT INTO quotes (author, author_id, quote, weight, source, attribution_status)
+            VALUES ((SELECT name FROM resolved), (SELECT id FROM resolved), $2, $3, $5, $6)
+            RETURNING id, quote, author, weight, author_id, source, attribution_status, created_at, updated_at
+        ), ` + revisionItem(models.RevisionCreate) + `, ` + tagWrites("$4") + `
+        SELECT id, quote, author, weight, author_id, source, attribution_status, $4::text[], created_at, updated_at FROM q
     `
-	_, err := db.Conn.Exec(ctx, query,
+	err := db.Conn.QueryRow(ctx, query,
 		quote.Author,
 		quote.Quote,
+		quote.Weight,
+		quote.Tags,
+		quote.Source,
+		quote.AttributionStatus,
+	).Scan(
+		&created.ID,
+		&created.Quote,
+		&created.Author,
+		&created.Weight,
+		&created.AuthorID,
+		&created.Source,
+		&created.AttributionStatus,
+		&created.Tags,
+		&created.CreatedAt,
+		&created.UpdatedAt,
 	)
 
 	if err != nil {
 		db.Log.Error("Failed to add quote", "error", err)
-		return err
+		return models.Quote{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
 	}
-	db.Log.Debug("Finished adding quote to DB")
+	db.ids.invalidate()
+	db.weights.invalidate()
+	db.Log.Debug("Finished adding quote to DB", "quote_id", created.ID)
 
-	return nil
+	return created, nil
 }
 
-func (db *DB) GetQuotes(ctx context.Context, filters models.QuoteFilter) ([]models.Quote, error) {
+func (db *DB) GetQuotes(ctx context.Context, filters models.QuoteFilter) (models.QuotePage, error) {
 	db.Log.Debug("started getting quote list DB")
-	var quotes []models.Quote
+	var page models.QuotePage
 
-	query := `
-		SELECT id, author, quote
-		FROM quotes
-	`
-	var args []any
+	limit := pageLimit(filters.Limit)
+	keys, err := orderKeys(filters)
+	if err != nil {
+		return models.QuotePage{}, err
+	}
+
+	b := &queryBuilder{}
+	if err := applyQuoteFilter(b, filters); err != nil {
+		return models.QuotePage{}, err
+	}
+
+	if filters.WithTotal {
+		total, err := db.countQuotes(ctx, b.clone())
+		if err != nil {
+			return models.QuotePage{}, err
+		}
+		page.Total = &total
+	}
+
+	var before bool
+	if filters.Cursor != "" {
+		values, isBefore, err := decodeCursor(filters.Cursor, keys)
+		if err != nil {
+			db.Log.Warn("failed to decode cursor", "cursor", filters.Cursor)
+			return models.QuotePage{}, err
+		}
+		before = isBefore
+		addKeysetCondition(b, keys, values, before)
+	}
+
+	columns := "id, author, quote, weight, author_id, source, attribution_status, " + tagsOf("quotes.id") + ", created_at, updated_at"
+	if filters.Trashed {
+		columns += ", deleted_at"
+	}
+	if filters.Search != "" {
+		columns += ", ts_headline(search.config, translate(quote, '" + headlineStart + headlineStop + "', ''), search.query, '" + headlineOptions + "')"
+	}
+
+	// id and author are already selected, other sort keys are selected
+	// separately so the boundary rows can be turned into cursors.
+	var extraKeys []models.SortKey
+	for _, k := range keys {
+		if k.Field != models.SortByID && k.Field != models.SortByAuthor {
+			columns += ", " + sortColumns[k.Field].expr
+			extraKeys = append(extraKeys, k)
+		}
+	}
 
-	if filters.Author != "" {
-		query += " WHERE author = $1"
-		args = append(args, filters.Author)
+	query := "SELECT " + columns + b.fromSQL() + b.whereSQL() + orderBySQL(keys, before) + " LIMIT " + b.arg(limit+1)
+	if filters.Offset > 0 {
+		query += " OFFSET " + b.arg(filters.Offset)
 	}
 
-	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "args", args)
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "args", b.args)
 
-	rows, err := db.Conn.Query(ctx, query, args...)
+	rows, err := db.Conn.Query(ctx, query, b.args...)
 	if err != nil {
 		db.Log.Error("failed to fetch quotes", "error", err)
-		return nil, err
+		return models.QuotePage{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
 	}
 	defer rows.Close()
 
+	quotes := []models.Quote{}
+	var rowKeys [][]any
 	for rows.Next() {
 		var q models.Quote
-		err := rows.Scan(
+		dest := []any{
 			&q.ID,
 			&q.Author,
 			&q.Quote,
-		)
-		if err != nil {
+			&q.Weight,
+			&q.AuthorID,
+			&q.Source,
+			&q.AttributionStatus,
+			&q.Tags,
+			&q.CreatedAt,
+			&q.UpdatedAt,
+		}
+		if filters.Trashed {
+			dest = append(dest, &q.DeletedAt)
+		}
+		if filters.Search != "" {
+			dest = append(dest, &q.Headline)
+		}
+		extras := make([]any, len(extraKeys))
+		for i, k := range extraKeys {
+			extras[i] = sortColumns[k.Field].kind.newValue()
+		}
+
+		if err := rows.Scan(append(dest, extras...)...); err != nil {
 			db.Log.Error("failed to scan quote row", "error", err)
-			return nil, err
+			return models.QuotePage{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		if filters.Search != "" {
+			q.Headline = headlineHTML(q.Headline)
 		}
 		quotes = append(quotes, q)
+		rowKeys = append(rowKeys, keyValues(keys, q, extras))
 	}
 
 	if err := rows.Err(); err != nil {
 		db.Log.Error("error while iterating over rows", "error", err)
-		return nil, err
+		return models.QuotePage{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	// One extra row was requested to learn whether there is more in the
+	// direction of travel.
+	hasMore := len(quotes) > limit
+	if hasMore {
+		quotes = quotes[:limit]
+		rowKeys = rowKeys[:limit]
+	}
+	if before {
+		slices.Reverse(quotes)
+		slices.Reverse(rowKeys)
+	}
+	page.Quotes = quotes
+
+	if len(quotes) > 0 {
+		hasNext, hasPrev := hasMore, filters.Cursor != "" || filters.Offset > 0
+		if before {
+			hasNext, hasPrev = true, hasMore
+		}
+		if hasNext {
+			page.NextCursor = encodeCursor(keys, rowKeys[len(rowKeys)-1], false)
+		}
+		if hasPrev {
+			page.PrevCursor = encodeCursor(keys, rowKeys[0], true)
+		}
 	}
 
-	db.Log.Debug("ended getting quote list DB")
-	return quotes, nil
+	db.Log.Debug("ended getting quote list DB", "count", len(quotes))
+	return page, nil
 }
 
-func (db *DB) GetRandomQuote(ctx context.Context) (models.Quote, error) {
-	db.Log.Debug("started getting random quote DB")
+// keyValues collects the sort key values of a scanned row in key order.
+func keyValues(keys []models.SortKey, q models.Quote, extras []any) []any {
+	values := make([]any, 0, len(keys))
+	for _, k := range keys {
+		switch k.Field {
+		case models.SortByID:
+			values = append(values, int64(q.ID))
+		case models.SortByAuthor:
+			values = append(values, q.Author)
+		default:
+			values = append(values, deref(extras[0]))
+			extras = extras[1:]
+		}
+	}
+	return values
+}
+
+// countQuotes returns the number of rows matched by the conditions in b.
+func (db *DB) countQuotes(ctx context.Context, b *queryBuilder) (int, error) {
+	query := "SELECT count(*)" + b.fromSQL() + b.whereSQL()
+
+	db.Log.Debug("executing query", "query", query, "args", b.args)
+
+	var total int
+	if err := db.Conn.QueryRow(ctx, query, b.args...).Scan(&total); err != nil {
+		db.Log.Error("failed to count quotes", "error", err)
+		return 0, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	return total, nil
+}
+
+func (db *DB) GetQuote(ctx context.Context, quoteID int) (models.Quote, error) {
+	db.Log.Debug("started getting quote DB", "quote_id", quoteID)
 	var quote models.Quote
 
 	query := `
-		SELECT id, quote, author
+		SELECT id, quote, author, weight, author_id, source, attribution_status, ` + tagsOf("quotes.id") + `, created_at, updated_at
 		FROM quotes
-		ORDER BY RANDOM()
-		LIMIT 1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	db.Log.Debug("executing query", "query", strings.TrimSpace(query))
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "quote_id", quoteID)
 
-	err := db.Conn.QueryRow(ctx, query).Scan(
+	err := db.Conn.QueryRow(ctx, query, quoteID).Scan(
 		&quote.ID,
 		&quote.Quote,
 		&quote.Author,
+		&quote.Weight,
+		&quote.AuthorID,
+		&quote.Source,
+		&quote.AttributionStatus,
+		&quote.Tags,
+		&quote.CreatedAt,
+		&quote.UpdatedAt,
 	)
 
 	if err != nil {
 		if stdErrors.Is(err, pgx.ErrNoRows) {
-			db.Log.Warn("no quotes was found in DB")
+			db.Log.Warn("no quote was found with the given id", "id", quoteID)
 			return models.Quote{}, errors.ErrQuoteNotFound
 		}
-		db.Log.Error("failed to fetch or scan random quote", "error", err)
-		return models.Quote{}, err
+		db.Log.Error("failed to fetch or scan quote", "error", err)
+		return models.Quote{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
 	}
 
-	db.Log.Debug("ended getting random quote DB", "quote_id", quote.ID)
+	db.Log.Debug("ended getting quote DB", "quote_id", quote.ID)
 	return quote, nil
 }
 
-func (db *DB) DeleteQuote(ctx context.Context, quoteID string) error {
+func (db *DB) UpdateQuote(ctx context.Context, quote models.Quote) (models.Quote, error) {
+	db.Log.Debug("started updating quote DB", "quote_id", quote.ID)
+	var updated models.Quote
+
+	query := `
+		WITH ` + lockLiveQuote("$4") + `, ` + resolveAuthor("$1", liveGuard) + `, q AS (
+			UPDATE quotes
+			SET author = (SELECT name FROM resolved), author_id = (SELECT id FROM resolved),
+				quote = $2, weight = $3, source = $6, attribution_status = $7, updated_at = now()
+			WHERE id = $4 AND deleted_at IS NULL
+			RETURNING id, quote, author, weight, author_id, source, attribution_status, created_at, updated_at
+		), ` + changedRevisionItem(models.RevisionUpdate) + `, ` + tagWrites("$5") + `
+		SELECT id, quote, author, weight, author_id, source, attribution_status, $5::text[], created_at, updated_at FROM q
+	`
+
+	err := db.Conn.QueryRow(ctx, query,
+		quote.Author, quote.Quote, quote.Weight, quote.ID, quote.Tags, quote.Source, quote.AttributionStatus).Scan(
+		&updated.ID,
+		&updated.Quote,
+		&updated.Author,
+		&updated.Weight,
+		&updated.AuthorID,
+		&updated.Source,
+		&updated.AttributionStatus,
+		&updated.Tags,
+		&updated.CreatedAt,
+		&updated.UpdatedAt,
+	)
+
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			db.Log.Warn("no quote was found with the given id", "id", quote.ID)
+			return models.Quote{}, errors.ErrQuoteNotFound
+		}
+		db.Log.Error("failed to update quote", "error", err)
+		return models.Quote{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.weights.invalidate()
+	db.Log.Debug("Finished updating quote DB", "quote_id", updated.ID)
+	return updated, nil
+}
+
+func (db *DB) PatchQuote(ctx context.Context, quoteID int, patch models.QuotePatch) (models.Quote, error) {
+	db.Log.Debug("started patching quote DB", "quote_id", quoteID)
+	var updated models.Quote
+
+	// The source patch is merged as in RFC 7396: its null members are
+	// stripped after the merge, as stored sources never hold nulls.
+	args := []any{patch.Author, patch.Quote, patch.Weight, quoteID, patch.Source, patch.AttributionStatus}
+	// The tags written by a statement are not visible to its own subqueries,
+	// so patched tags are returned from the parameter.
+	tags, writes := tagsOf("q.id"), ""
+	if patch.Tags != nil {
+		args = append(args, *patch.Tags)
+		tags, writes = "$7::text[]", ", "+tagWrites("$7")
+	}
+
+	query := `
+		WITH ` + lockLiveQuote("$4") + `, ` + resolveAuthor("$1", liveGuard) + `, q AS (
+			UPDATE quotes
+			SET author = COALESCE((SELECT name FROM resolved), author),
+				author_id = COALESCE((SELECT id FROM resolved), author_id),
+				quote = COALESCE($2, quote), weight = COALESCE($3, weight),
+				source = COALESCE(jsonb_strip_nulls(source || $5::jsonb), source), attribution_status = COALESCE($6, attribution_status), updated_at = now()
+			WHERE id = $4 AND deleted_at IS NULL
+			RETURNING id, quote, author, weight, author_id, source, attribution_status, created_at, updated_at
+		), ` + changedRevisionItem(models.RevisionUpdate) + writes + `
+		SELECT id, quote, author, weight, author_id, source, attribution_status, ` + tags + `, created_at, updated_at FROM q
+	`
+
+	err := db.Conn.QueryRow(ctx, query, args...).Scan(
+		&updated.ID,
+		&updated.Quote,
+		&updated.Author,
+		&updated.Weight,
+		&updated.AuthorID,
+		&updated.Source,
+		&updated.AttributionStatus,
+		&updated.Tags,
+		&updated.CreatedAt,
+		&updated.UpdatedAt,
+	)
+
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			db.Log.Warn("no quote was found with the given id", "id", quoteID)
+			return models.Quote{}, errors.ErrQuoteNotFound
+		}
+		db.Log.Error("failed to patch quote", "error", err)
+		return models.Quote{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.weights.invalidate()
+	db.Log.Debug("Finished patching quote DB", "quote_id", updated.ID)
+	return updated, nil
+}
+
+// DeleteQuote moves a quote to the trash, see RestoreQuote and PurgeQuote.
+func (db *DB) DeleteQuote(ctx context.Context, quoteID int) error {
 	db.Log.Debug("started deleting quote from DB")
 
-	query := `DELETE FROM quotes WHERE id = $1`
+	query := `
+		WITH q AS (
+			UPDATE quotes SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id, quote, author, weight
+		)
+	` + revisionInsert(models.RevisionDelete)
 
 	result, err := db.Conn.Exec(ctx, query, quoteID)
 	if err != nil {
 		db.Log.Error("failed to delete quote", "error", err)
-		return err
+		return fmt.Errorf("%w: %w", errors.ErrExecDB, err)
 	}
 
 	rowsAffected := result.RowsAffected()
@@ -173,6 +493,8 @@ func (db *DB) DeleteQuote(ctx context.Context, quoteID string) error {
 		db.Log.Warn("no quote was found with the given id", "id", quoteID)
 		return errors.ErrQuoteNotFound
 	}
+	db.ids.invalidate()
+	db.weights.invalidate()
 	db.Log.Debug("Finished deleting quote from DB")
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v4"
@@ -22,6 +23,10 @@ func newTestLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
 
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestDB_AddQuote(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
@@ -44,6 +49,7 @@ func TestDB_AddQuote(t *testing.T) {
 		name         string
 		mockBehavior mockBehavior
 		args         args
+		expected     models.Quote
 		wantErr      bool
 	}{
 		{
@@ -51,14 +57,24 @@ func TestDB_AddQuote(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				quote: models.Quote{
-					Author: "Test Author",
-					Quote:  "Test Quote",
+					Author:            "Test Author",
+					Quote:             "Test Quote",
+					Weight:            1,
+					Tags:              []string{"stoicism"},
+					Source:            models.Source{Title: "Letters to Lucilius"},
+					AttributionStatus: models.AttributionVerified,
 				},
 			},
 			mockBehavior: func(args args) {
-				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO quotes (author, quote) VALUES ($1, $2)`)).
-					WithArgs(args.quote.Author, args.quote.Quote).
-					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				rows := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(7, args.quote.Quote, args.quote.Author, args.quote.Weight, 3, args.quote.Source, "verified", args.quote.Tags, time.Time{}, time.Time{})
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO quotes (author, author_id, quote, weight, source, attribution_status) VALUES ((SELECT name FROM resolved), (SELECT id FROM resolved), $2, $3, $5, $6) RETURNING id, quote, author, weight, author_id, source, attribution_status, created_at, updated_at`)).
+					WithArgs(args.quote.Author, args.quote.Quote, args.quote.Weight, args.quote.Tags, args.quote.Source, args.quote.AttributionStatus).
+					WillReturnRows(rows)
+			},
+			expected: models.Quote{
+				ID: 7, Author: "Test Author", AuthorID: 3, Quote: "Test Quote", Weight: 1, Tags: []string{"stoicism"},
+				Source: models.Source{Title: "Letters to Lucilius"}, AttributionStatus: models.AttributionVerified,
 			},
 			wantErr: false,
 		},
@@ -69,11 +85,12 @@ func TestDB_AddQuote(t *testing.T) {
 				quote: models.Quote{
 					Author: "Test Author",
 					Quote:  "Test Quote",
+					Weight: 1,
 				},
 			},
 			mockBehavior: func(args args) {
-				mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO quotes (author, quote) VALUES ($1, $2)`)).
-					WithArgs(args.quote.Author, args.quote.Quote).
+				mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO quotes (author, author_id, quote, weight, source, attribution_status)`)).
+					WithArgs(args.quote.Author, args.quote.Quote, args.quote.Weight, args.quote.Tags, args.quote.Source, args.quote.AttributionStatus).
 					WillReturnError(stdErrors.New("db insert error"))
 			},
 			wantErr: true,
@@ -84,11 +101,12 @@ func TestDB_AddQuote(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			testCase.mockBehavior(testCase.args)
 
-			err := r.AddQuote(testCase.args.ctx, testCase.args.quote)
+			quote, err := r.AddQuote(testCase.args.ctx, testCase.args.quote)
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, testCase.expected, quote)
 			}
 			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
 		})
@@ -106,6 +124,8 @@ func TestDB_GetQuotes(t *testing.T) {
 		Conn: mock,
 	}
 
+	sourceYear := 65
+
 	type args struct {
 		ctx     context.Context
 		filters models.QuoteFilter
@@ -118,6 +138,9 @@ func TestDB_GetQuotes(t *testing.T) {
 		mockBehavior mockBehavior
 		args         args
 		expected     []models.Quote
+		wantNext     bool
+		wantPrev     bool
+		wantTotal    *int
 		wantErr      bool
 	}{
 		{
@@ -127,14 +150,16 @@ func TestDB_GetQuotes(t *testing.T) {
 				filters: models.QuoteFilter{},
 			},
 			mockBehavior: func(args args) {
-				rows := pgxmock.NewRows([]string{"id", "author", "quote"}).
-					AddRow(1, "Author1", "Quote1").
-					AddRow(2, "Author2", "Quote2")
-				mock.ExpectQuery(`SELECT id, author, quote FROM quotes`).WillReturnRows(rows)
+				rows := pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Author1", "Quote1", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}).
+					AddRow(2, "Author2", "Quote2", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+				mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL ORDER BY id ASC LIMIT \$1`).
+					WithArgs(models.DefaultPageLimit + 1).
+					WillReturnRows(rows)
 			},
 			expected: []models.Quote{
-				{ID: 1, Author: "Author1", Quote: "Quote1"},
-				{ID: 2, Author: "Author2", Quote: "Quote2"},
+				{ID: 1, Author: "Author1", Quote: "Quote1", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+				{ID: 2, Author: "Author2", Quote: "Quote2", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
 			},
 			wantErr: false,
 		},
@@ -145,17 +170,101 @@ func TestDB_GetQuotes(t *testing.T) {
 				filters: models.QuoteFilter{Author: "Author1"},
 			},
 			mockBehavior: func(args args) {
-				rows := pgxmock.NewRows([]string{"id", "author", "quote"}).
-					AddRow(1, "Author1", "Quote1")
-				mock.ExpectQuery(`SELECT id, author, quote FROM quotes WHERE author = \$1`).
-					WithArgs("Author1").
+				rows := pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Author1", "Quote1", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+				mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL AND author_id IN \(SELECT id FROM authors WHERE lower\(name\) = lower\(\$1\) AND name = \$1 UNION ALL SELECT author_id FROM author_aliases WHERE lower\(alias\) = lower\(\$1\) AND alias = \$1\) ORDER BY id ASC LIMIT \$2`).
+					WithArgs("Author1", models.DefaultPageLimit+1).
 					WillReturnRows(rows)
 			},
 			expected: []models.Quote{
-				{ID: 1, Author: "Author1", Quote: "Quote1"},
+				{ID: 1, Author: "Author1", Quote: "Quote1", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+			},
+			wantErr: false,
+		},
+		{
+			name: "OK - With attribution status filter",
+			args: args{
+				ctx:     context.Background(),
+				filters: models.QuoteFilter{AttributionStatuses: []models.AttributionStatus{models.AttributionVerified, models.AttributionDisputed}},
+			},
+			mockBehavior: func(args args) {
+				rows := pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Seneca", "Quote1", 0.0, 2, models.Source{Title: "Letters to Lucilius", Year: &sourceYear}, "verified", []string{}, time.Time{}, time.Time{})
+				mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL AND attribution_status = ANY\(\$1\) ORDER BY id ASC LIMIT \$2`).
+					WithArgs(args.filters.AttributionStatuses, models.DefaultPageLimit+1).
+					WillReturnRows(rows)
 			},
+			expected: []models.Quote{{
+				ID: 1, Author: "Seneca", AuthorID: 2, Quote: "Quote1", Tags: []string{},
+				Source: models.Source{Title: "Letters to Lucilius", Year: &sourceYear}, AttributionStatus: models.AttributionVerified,
+			}},
 			wantErr: false,
 		},
+		{
+			name: "OK - Created between",
+			args: args{
+				ctx: context.Background(),
+				filters: models.QuoteFilter{
+					CreatedAfter:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					CreatedBefore: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			mockBehavior: func(args args) {
+				createdAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+				updatedAt := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+				rows := pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Author1", "Quote1", 1.0, 0, models.Source{}, "unknown", []string{}, createdAt, updatedAt)
+				mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL AND created_at > \$1 AND created_at < \$2 ORDER BY id ASC LIMIT \$3`).
+					WithArgs(args.filters.CreatedAfter, args.filters.CreatedBefore, models.DefaultPageLimit+1).
+					WillReturnRows(rows)
+			},
+			expected: []models.Quote{{
+				ID:                1,
+				Author:            "Author1",
+				Quote:             "Quote1",
+				Weight:            1,
+				Tags:              []string{},
+				AttributionStatus: models.AttributionUnknown,
+				CreatedAt:         time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+				UpdatedAt:         time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC),
+			}},
+			wantErr: false,
+		},
+		{
+			name: "OK - Offset with more rows and total",
+			args: args{
+				ctx:     context.Background(),
+				filters: models.QuoteFilter{Limit: 2, Offset: 2, WithTotal: true},
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery(`SELECT count\(\*\) FROM quotes`).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(5))
+				rows := pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(3, "Author3", "Quote3", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}).
+					AddRow(4, "Author4", "Quote4", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}).
+					AddRow(5, "Author5", "Quote5", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+				mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL ORDER BY id ASC LIMIT \$1 OFFSET \$2`).
+					WithArgs(3, 2).
+					WillReturnRows(rows)
+			},
+			expected: []models.Quote{
+				{ID: 3, Author: "Author3", Quote: "Quote3", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+				{ID: 4, Author: "Author4", Quote: "Quote4", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+			},
+			wantNext:  true,
+			wantPrev:  true,
+			wantTotal: ptr(5),
+			wantErr:   false,
+		},
+		{
+			name: "Invalid cursor",
+			args: args{
+				ctx:     context.Background(),
+				filters: models.QuoteFilter{Cursor: "not a cursor"},
+			},
+			mockBehavior: func(args args) {},
+			wantErr:      true,
+		},
 		{
 			name: "Query Error",
 			args: args{
@@ -163,7 +272,8 @@ func TestDB_GetQuotes(t *testing.T) {
 				filters: models.QuoteFilter{},
 			},
 			mockBehavior: func(args args) {
-				mock.ExpectQuery(`SELECT id, author, quote FROM quotes`).
+				mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes`).
+					WithArgs(models.DefaultPageLimit + 1).
 					WillReturnError(stdErrors.New("db query error"))
 			},
 			expected: nil,
@@ -176,10 +286,12 @@ func TestDB_GetQuotes(t *testing.T) {
 				filters: models.QuoteFilter{},
 			},
 			mockBehavior: func(args args) {
-				rows := pgxmock.NewRows([]string{"id", "author", "quote"}).
-					AddRow("1", "Author1", "Quote1").
+				rows := pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow("1", "Author1", "Quote1", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}).
 					RowError(0, stdErrors.New("scan error for row 0"))
-				mock.ExpectQuery(`SELECT id, author, quote FROM quotes`).WillReturnRows(rows)
+				mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes`).
+					WithArgs(models.DefaultPageLimit + 1).
+					WillReturnRows(rows)
 			},
 			expected: nil,
 			wantErr:  true,
@@ -191,18 +303,208 @@ func TestDB_GetQuotes(t *testing.T) {
 
 			testCase.mockBehavior(testCase.args)
 
-			quotes, err := r.GetQuotes(testCase.args.ctx, testCase.args.filters)
+			page, err := r.GetQuotes(testCase.args.ctx, testCase.args.filters)
 			if testCase.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, testCase.expected, quotes)
+				assert.Equal(t, testCase.expected, page.Quotes)
+				assert.Equal(t, testCase.wantNext, page.NextCursor != "", "next cursor presence")
+				assert.Equal(t, testCase.wantPrev, page.PrevCursor != "", "prev cursor presence")
+				assert.Equal(t, testCase.wantTotal, page.Total)
 			}
 			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
 		})
 	}
 }
-func TestDB_GetRandomQuote(t *testing.T) {
+
+func TestDB_GetQuotes_CursorRoundTrip(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+	ctx := context.Background()
+
+	mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL ORDER BY id ASC LIMIT \$1`).
+		WithArgs(2).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+			AddRow(1, "Author1", "Quote1", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}).
+			AddRow(2, "Author2", "Quote2", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}).
+			AddRow(3, "Author3", "Quote3", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}))
+
+	first, err := r.GetQuotes(ctx, models.QuoteFilter{Limit: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor)
+
+	mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL AND id > \$1 ORDER BY id ASC LIMIT \$2`).
+		WithArgs(int64(1), 2).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+			AddRow(2, "Author2", "Quote2", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}))
+
+	second, err := r.GetQuotes(ctx, models.QuoteFilter{Limit: 1, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Quote{{ID: 2, Author: "Author2", Quote: "Quote2", Tags: []string{}, AttributionStatus: models.AttributionUnknown}}, second.Quotes)
+	assert.Empty(t, second.NextCursor)
+	require.NotEmpty(t, second.PrevCursor)
+
+	mock.ExpectQuery(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE deleted_at IS NULL AND id < \$1 ORDER BY id DESC LIMIT \$2`).
+		WithArgs(int64(2), 2).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+			AddRow(1, "Author1", "Quote1", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}))
+
+	back, err := r.GetQuotes(ctx, models.QuoteFilter{Limit: 1, Cursor: second.PrevCursor})
+	require.NoError(t, err)
+	assert.Equal(t, first.Quotes, back.Quotes)
+	assert.NotEmpty(t, back.NextCursor)
+	assert.Empty(t, back.PrevCursor)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+}
+
+func TestDB_GetQuotes_Sorted(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+	ctx := context.Background()
+
+	sort := []models.SortKey{
+		{Field: models.SortByPopularity, Desc: true},
+		{Field: models.SortByAuthor},
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id ORDER BY t.slug), created_at, updated_at, weight FROM quotes WHERE deleted_at IS NULL ORDER BY weight DESC, author ASC, id ASC LIMIT $1`)).
+		WithArgs(2).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at", "weight"}).
+			AddRow(5, "Author5", "Quote5", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}, 10.0).
+			AddRow(3, "Author3", "Quote3", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}, 7.0))
+
+	first, err := r.GetQuotes(ctx, models.QuoteFilter{Limit: 1, Sort: sort})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Quote{{ID: 5, Author: "Author5", Quote: "Quote5", Tags: []string{}, AttributionStatus: models.AttributionUnknown}}, first.Quotes)
+	require.NotEmpty(t, first.NextCursor)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id ORDER BY t.slug), created_at, updated_at, weight FROM quotes `+
+		`WHERE deleted_at IS NULL AND ((weight < $1) OR (weight = $1 AND author > $2) OR (weight = $1 AND author = $2 AND id > $3)) `+
+		`ORDER BY weight DESC, author ASC, id ASC LIMIT $4`)).
+		WithArgs(10.0, "Author5", int64(5), 2).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at", "weight"}).
+			AddRow(3, "Author3", "Quote3", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}, 7.0))
+
+	second, err := r.GetQuotes(ctx, models.QuoteFilter{Limit: 1, Sort: sort, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Quote{{ID: 3, Author: "Author3", Quote: "Quote3", Tags: []string{}, AttributionStatus: models.AttributionUnknown}}, second.Quotes)
+
+	_, err = r.GetQuotes(ctx, models.QuoteFilter{Limit: 1, Cursor: first.NextCursor})
+	assert.ErrorIs(t, err, repositories.ErrInvalidCursor, "cursor must not be reusable with another ordering")
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+}
+
+func TestDB_GetQuotes_Search(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+	ctx := context.Background()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id ORDER BY t.slug), created_at, updated_at, ts_headline(search.config, translate(quote, '`+"\uE000\uE001"+`', ''), search.query, `)+
+		`.*`+regexp.QuoteMeta(`, rank FROM quotes, `+
+		`(SELECT $1::regconfig AS config, websearch_to_tsquery($1::regconfig, $2) AS query) AS search, `+
+		`ts_rank(quotes.search_russian, search.query) AS rank `+
+		`WHERE deleted_at IS NULL AND quotes.search_russian @@ search.query ORDER BY rank DESC, id ASC LIMIT $3`)).
+		WithArgs("russian", "смелость", models.DefaultPageLimit+1).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at", "ts_headline", "rank"}).
+			AddRow(4, "Author4", "Смелость города берёт", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}, "\uE000Смелость\uE001 города берёт", 0.6))
+
+	page, err := r.GetQuotes(ctx, models.QuoteFilter{Search: "смелость", Language: models.SearchRussian})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Quote{{
+		ID:                4,
+		Author:            "Author4",
+		Quote:             "Смелость города берёт",
+		Tags:              []string{},
+		Headline:          "<mark>Смелость</mark> города берёт",
+		AttributionStatus: models.AttributionUnknown,
+	}}, page.Quotes)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`ts_headline(`)).
+		WithArgs("english", "courage", models.DefaultPageLimit+1).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at", "ts_headline", "rank"}).
+			AddRow(5, "Author5", `<script>alert("courage")</script> & more`, 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}, "<script>alert(\"\uE000courage\uE001\")</script> & more", 0.3))
+
+	page, err = r.GetQuotes(ctx, models.QuoteFilter{Search: "courage", Language: models.SearchEnglish})
+	require.NoError(t, err)
+	require.Len(t, page.Quotes, 1)
+	assert.Equal(t, `&lt;script&gt;alert(&#34;<mark>courage</mark>&#34;)&lt;/script&gt; &amp; more`, page.Quotes[0].Headline,
+		"markup typed into a quote must be escaped, only the matches are marked")
+
+	_, err = r.GetQuotes(ctx, models.QuoteFilter{Search: "courage", Language: "klingon"})
+	assert.ErrorIs(t, err, repositories.ErrUnknownSearchLanguage)
+
+	_, err = r.GetQuotes(ctx, models.QuoteFilter{Sort: []models.SortKey{{Field: models.SortByRank}}})
+	assert.ErrorIs(t, err, repositories.ErrRankWithoutSearch)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+}
+
+func TestDB_GetQuotes_AuthorMatch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	testTable := []struct {
+		name      string
+		match     models.AuthorMatch
+		author    string
+		condition string
+		arg       string
+	}{
+		{name: "Default exact", match: "", author: "Confucius", condition: `author_id IN (SELECT id FROM authors WHERE lower(name) = lower($1) AND name = $1 UNION ALL SELECT author_id FROM author_aliases WHERE lower(alias) = lower($1) AND alias = $1)`, arg: "Confucius"},
+		{name: "Case-insensitive", match: models.AuthorMatchInsensitive, author: "confucius", condition: `author_id IN (SELECT id FROM authors WHERE lower(name) = lower($1) UNION ALL SELECT author_id FROM author_aliases WHERE lower(alias) = lower($1))`, arg: "confucius"},
+		{name: "Prefix", match: models.AuthorMatchPrefix, author: "Confuc", condition: `lower(author) LIKE $1`, arg: "confuc%"},
+		{name: "Prefix with wildcards", match: models.AuthorMatchPrefix, author: "100%_", condition: `lower(author) LIKE $1`, arg: `100\%\_%`},
+		{name: "Substring", match: models.AuthorMatchSubstring, author: "fuci", condition: `author ILIKE $1`, arg: "%fuci%"},
+		{name: "Fuzzy", match: models.AuthorMatchFuzzy, author: "Konfucius", condition: `author % $1`, arg: "Konfucius"},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id ORDER BY t.slug), created_at, updated_at FROM quotes WHERE deleted_at IS NULL AND `+testCase.condition+` ORDER BY id ASC LIMIT $2`)).
+				WithArgs(testCase.arg, models.DefaultPageLimit+1).
+				WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Confucius", "Quote1", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}))
+
+			page, err := r.GetQuotes(context.Background(), models.QuoteFilter{Author: testCase.author, AuthorMatch: testCase.match})
+			assert.NoError(t, err)
+			assert.Len(t, page.Quotes, 1)
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+
+	_, err = r.GetQuotes(context.Background(), models.QuoteFilter{Author: "Confucius", AuthorMatch: "soundex"})
+	assert.ErrorIs(t, err, repositories.ErrUnknownAuthorMatch)
+}
+
+func TestDB_GetQuote(t *testing.T) {
 	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherRegexp))
 	require.NoError(t, err)
 	defer mock.Close()
@@ -214,12 +516,15 @@ func TestDB_GetRandomQuote(t *testing.T) {
 	}
 
 	type args struct {
-		ctx context.Context
+		ctx     context.Context
+		quoteID int
 	}
 
+	type mockBehavior func(args args)
+
 	testTable := []struct {
 		name         string
-		mockBehavior func()
+		mockBehavior mockBehavior
 		args         args
 		expected     models.Quote
 		wantErr      bool
@@ -227,22 +532,23 @@ func TestDB_GetRandomQuote(t *testing.T) {
 	}{
 		{
 			name: "OK",
-			args: args{ctx: context.Background()},
-			mockBehavior: func() {
-				rows := pgxmock.NewRows([]string{"id", "quote", "author"}).
-					AddRow(1, "Random Quote", "Random Author")
-				mock.ExpectQuery(`SELECT id, quote, author FROM quotes ORDER BY RANDOM\(\) LIMIT 1`).
+			args: args{ctx: context.Background(), quoteID: 1},
+			mockBehavior: func(args args) {
+				rows := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Quote1", "Author1", 0.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{})
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = \$1`).
+					WithArgs(args.quoteID).
 					WillReturnRows(rows)
 			},
-			expected:    models.Quote{ID: 1, Quote: "Random Quote", Author: "Random Author"},
-			wantErr:     false,
-			expectedErr: nil,
+			expected: models.Quote{ID: 1, Quote: "Quote1", Author: "Author1", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+			wantErr:  false,
 		},
 		{
 			name: "No Rows - ErrQuoteNotFound",
-			args: args{ctx: context.Background()},
-			mockBehavior: func() {
-				mock.ExpectQuery(`SELECT id, quote, author FROM quotes ORDER BY RANDOM\(\) LIMIT 1`).
+			args: args{ctx: context.Background(), quoteID: 42},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = \$1`).
+					WithArgs(args.quoteID).
 					WillReturnError(pgx.ErrNoRows)
 			},
 			expected:    models.Quote{},
@@ -251,9 +557,10 @@ func TestDB_GetRandomQuote(t *testing.T) {
 		},
 		{
 			name: "DB Error",
-			args: args{ctx: context.Background()},
-			mockBehavior: func() {
-				mock.ExpectQuery(`SELECT id, quote, author FROM quotes ORDER BY RANDOM\(\) LIMIT 1`).
+			args: args{ctx: context.Background(), quoteID: 1},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery(`SELECT id, quote, author, weight, author_id, source, attribution_status, ARRAY\(SELECT t\.slug FROM quote_tags qt JOIN tags t ON t\.id = qt\.tag_id WHERE qt\.quote_id = quotes\.id ORDER BY t\.slug\), created_at, updated_at FROM quotes WHERE id = \$1`).
+					WithArgs(args.quoteID).
 					WillReturnError(errors.ErrQuery)
 			},
 			expected:    models.Quote{},
@@ -264,22 +571,218 @@ func TestDB_GetRandomQuote(t *testing.T) {
 
 	for _, testCase := range testTable {
 		t.Run(testCase.name, func(t *testing.T) {
-			testCase.mockBehavior()
+			testCase.mockBehavior(testCase.args)
 
-			actualQuote, actualErr := r.GetRandomQuote(testCase.args.ctx)
+			actualQuote, actualErr := r.GetQuote(testCase.args.ctx, testCase.args.quoteID)
 
 			if testCase.wantErr {
 				assert.Error(t, actualErr, "Expected an error")
 				if testCase.expectedErr != nil {
-					if !assert.ErrorIs(t, actualErr, testCase.expectedErr) {
-						assert.EqualError(t, actualErr, testCase.expectedErr.Error(), "Error message mismatch")
-					}
+					assert.ErrorIs(t, actualErr, testCase.expectedErr)
+				}
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actualQuote, "Quote data mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_UpdateQuote(t *testing.T) {
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := newTestLogger()
+	r := &repositories.DB{
+		Log:  logger,
+		Conn: mock,
+	}
+
+	type args struct {
+		ctx   context.Context
+		quote models.Quote
+	}
+
+	type mockBehavior func(args args)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		args         args
+		expected     models.Quote
+		wantErr      bool
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				quote: models.Quote{ID: 1, Author: "New Author", Quote: "New Quote", Weight: 2.5, Tags: []string{"life"}},
+			},
+			mockBehavior: func(args args) {
+				rows := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "New Quote", "New Author", 2.5, 5, models.Source{}, "unknown", []string{"life"}, time.Time{}, time.Time{})
+				mock.ExpectQuery(`UPDATE quotes SET author = \(SELECT name FROM resolved\), author_id = \(SELECT id FROM resolved\), quote = \$2, weight = \$3, source = \$6, attribution_status = \$7, updated_at = now\(\) WHERE id = \$4 AND deleted_at IS NULL RETURNING id, quote, author, weight, author_id, source, attribution_status, created_at, updated_at`).
+					WithArgs(args.quote.Author, args.quote.Quote, args.quote.Weight, args.quote.ID, args.quote.Tags, args.quote.Source, args.quote.AttributionStatus).
+					WillReturnRows(rows)
+			},
+			expected: models.Quote{ID: 1, Author: "New Author", AuthorID: 5, Quote: "New Quote", Weight: 2.5, Tags: []string{"life"}, AttributionStatus: models.AttributionUnknown},
+			wantErr:  false,
+		},
+		{
+			name: "Quote Not Found - ErrQuoteNotFound",
+			args: args{
+				ctx:   context.Background(),
+				quote: models.Quote{ID: 42, Author: "New Author", Quote: "New Quote"},
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery(`WITH live AS \(SELECT id, author, quote, weight FROM quotes WHERE id = \$4 AND deleted_at IS NULL FOR UPDATE\), .*WHERE \(\$1\)::text IS NOT NULL AND NOT EXISTS \(SELECT 1 FROM alias_match\) AND EXISTS \(SELECT 1 FROM live\) .*UPDATE quotes SET author = \(SELECT name FROM resolved\), author_id = \(SELECT id FROM resolved\), quote = \$2, weight = \$3, source = \$6, attribution_status = \$7, updated_at = now\(\) WHERE id = \$4`).
+					WithArgs(args.quote.Author, args.quote.Quote, args.quote.Weight, args.quote.ID, args.quote.Tags, args.quote.Source, args.quote.AttributionStatus).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expected:    models.Quote{},
+			wantErr:     true,
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			actualQuote, actualErr := r.UpdateQuote(testCase.args.ctx, testCase.args.quote)
+
+			if testCase.wantErr {
+				assert.Error(t, actualErr, "Expected an error")
+				if testCase.expectedErr != nil {
+					assert.ErrorIs(t, actualErr, testCase.expectedErr)
+				}
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actualQuote, "Quote data mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_PatchQuote(t *testing.T) {
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := newTestLogger()
+	r := &repositories.DB{
+		Log:  logger,
+		Conn: mock,
+	}
+
+	newAuthor := "New Author"
+
+	type args struct {
+		ctx     context.Context
+		quoteID int
+		patch   models.QuotePatch
+	}
+
+	type mockBehavior func(args args)
+
+	testTable := []struct {
+		name         string
+		mockBehavior mockBehavior
+		args         args
+		expected     models.Quote
+		wantErr      bool
+		expectedErr  error
+	}{
+		{
+			name: "OK - Author only",
+			args: args{
+				ctx:     context.Background(),
+				quoteID: 1,
+				patch:   models.QuotePatch{Author: &newAuthor},
+			},
+			mockBehavior: func(args args) {
+				rows := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Old Quote", "New Author", 1.0, 0, models.Source{}, "unknown", []string{"old"}, time.Time{}, time.Time{})
+				mock.ExpectQuery(`UPDATE quotes SET author = COALESCE\(\(SELECT name FROM resolved\), author\), author_id = COALESCE\(\(SELECT id FROM resolved\), author_id\), quote = COALESCE\(\$2, quote\), weight = COALESCE\(\$3, weight\), source = COALESCE\(jsonb_strip_nulls\(source \|\| \$5::jsonb\), source\), attribution_status = COALESCE\(\$6, attribution_status\), updated_at = now\(\) WHERE id = \$4`).
+					WithArgs(args.patch.Author, args.patch.Quote, args.patch.Weight, args.quoteID, args.patch.Source, args.patch.AttributionStatus).
+					WillReturnRows(rows)
+			},
+			expected: models.Quote{ID: 1, Author: "New Author", Quote: "Old Quote", Weight: 1, Tags: []string{"old"}, AttributionStatus: models.AttributionUnknown},
+			wantErr:  false,
+		},
+		{
+			name: "OK - Tags only",
+			args: args{
+				ctx:     context.Background(),
+				quoteID: 1,
+				patch:   models.QuotePatch{Tags: &[]string{"life", "stoicism"}},
+			},
+			mockBehavior: func(args args) {
+				rows := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Old Quote", "Old Author", 1.0, 0, models.Source{}, "unknown", *args.patch.Tags, time.Time{}, time.Time{})
+				// Only tags change, so the revision insert skips the row.
+				mock.ExpectQuery(`FROM q, live WHERE \(q\.author, q\.quote, q\.weight\) IS DISTINCT FROM \(live\.author, live\.quote, live\.weight\) \).*`+
+					`INSERT INTO tags \(slug\) SELECT unnest\(\$7::text\[\]\) WHERE EXISTS \(SELECT 1 FROM q\) .*INSERT INTO quote_tags \(quote_id, tag_id\) SELECT q\.id, tag_ids\.id FROM q, tag_ids ON CONFLICT DO NOTHING \) SELECT id, quote, author, weight, author_id, source, attribution_status, \$7::text\[\]`).
+					WithArgs(args.patch.Author, args.patch.Quote, args.patch.Weight, args.quoteID, args.patch.Source, args.patch.AttributionStatus, *args.patch.Tags).
+					WillReturnRows(rows)
+			},
+			expected: models.Quote{ID: 1, Author: "Old Author", Quote: "Old Quote", Weight: 1, Tags: []string{"life", "stoicism"}, AttributionStatus: models.AttributionUnknown},
+			wantErr:  false,
+		},
+		{
+			name: "OK - Partial source",
+			args: args{
+				ctx:     context.Background(),
+				quoteID: 1,
+				patch:   models.QuotePatch{Source: &models.SourcePatch{Members: []string{"page", "year"}, Source: models.Source{Page: "12"}}},
+			},
+			mockBehavior: func(args args) {
+				rows := pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Old Quote", "Old Author", 1.0, 0, models.Source{Title: "Analects", Page: "12"}, "unknown", []string{}, time.Time{}, time.Time{})
+				mock.ExpectQuery(`source = COALESCE\(jsonb_strip_nulls\(source \|\| \$5::jsonb\), source\)`).
+					WithArgs(args.patch.Author, args.patch.Quote, args.patch.Weight, args.quoteID, args.patch.Source, args.patch.AttributionStatus).
+					WillReturnRows(rows)
+			},
+			expected: models.Quote{ID: 1, Author: "Old Author", Quote: "Old Quote", Weight: 1, Tags: []string{}, Source: models.Source{Title: "Analects", Page: "12"}, AttributionStatus: models.AttributionUnknown},
+			wantErr:  false,
+		},
+		{
+			name: "Quote Not Found - ErrQuoteNotFound",
+			args: args{
+				ctx:     context.Background(),
+				quoteID: 42,
+				patch:   models.QuotePatch{Author: &newAuthor},
+			},
+			mockBehavior: func(args args) {
+				mock.ExpectQuery(`WITH live AS \(SELECT id, author, quote, weight FROM quotes WHERE id = \$4 AND deleted_at IS NULL FOR UPDATE\), .*WHERE \(\$1\)::text IS NOT NULL AND NOT EXISTS \(SELECT 1 FROM alias_match\) AND EXISTS \(SELECT 1 FROM live\) .*UPDATE quotes SET author = COALESCE`).
+					WithArgs(args.patch.Author, args.patch.Quote, args.patch.Weight, args.quoteID, args.patch.Source, args.patch.AttributionStatus).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expected:    models.Quote{},
+			wantErr:     true,
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.args)
+
+			actualQuote, actualErr := r.PatchQuote(testCase.args.ctx, testCase.args.quoteID, testCase.args.patch)
+
+			if testCase.wantErr {
+				assert.Error(t, actualErr, "Expected an error")
+				if testCase.expectedErr != nil {
+					assert.ErrorIs(t, actualErr, testCase.expectedErr)
 				}
-				assert.Equal(t, testCase.expected, actualQuote, "Quote data on error mismatch")
 			} else {
 				assert.NoError(t, actualErr, "Did not expect an error")
-				assert.Equal(t, testCase.expected, actualQuote, "Quote data on success mismatch")
 			}
+			assert.Equal(t, testCase.expected, actualQuote, "Quote data mismatch")
 			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
 		})
 	}
@@ -298,7 +801,7 @@ func TestDB_DeleteQuote(t *testing.T) {
 
 	type args struct {
 		ctx     context.Context
-		quoteID string
+		quoteID int
 	}
 
 	type mockBehavior func(args args)
@@ -314,12 +817,12 @@ func TestDB_DeleteQuote(t *testing.T) {
 			name: "OK - Quote deleted",
 			args: args{
 				ctx:     context.Background(),
-				quoteID: "1",
+				quoteID: 1,
 			},
 			mockBehavior: func(args args) {
-				mock.ExpectExec(`DELETE FROM quotes WHERE id = \$1`).
+				mock.ExpectExec(`UPDATE quotes SET deleted_at = now\(\) WHERE id = \$1 AND deleted_at IS NULL`).
 					WithArgs(args.quoteID).
-					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 			},
 			wantErr: false,
 		},
@@ -327,12 +830,12 @@ func TestDB_DeleteQuote(t *testing.T) {
 			name: "Quote Not Found - ErrQuoteNotFound",
 			args: args{
 				ctx:     context.Background(),
-				quoteID: "idNotExists",
+				quoteID: 42,
 			},
 			mockBehavior: func(args args) {
-				mock.ExpectExec(`DELETE FROM quotes WHERE id = \$1`).
+				mock.ExpectExec(`UPDATE quotes SET deleted_at = now\(\) WHERE id = \$1 AND deleted_at IS NULL`).
 					WithArgs(args.quoteID).
-					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
 			},
 			wantErr:     true,
 			expectedErr: errors.ErrQuoteNotFound,
@@ -341,10 +844,10 @@ func TestDB_DeleteQuote(t *testing.T) {
 			name: "DB Error on exec",
 			args: args{
 				ctx:     context.Background(),
-				quoteID: "1",
+				quoteID: 1,
 			},
 			mockBehavior: func(args args) {
-				mock.ExpectExec(`DELETE FROM quotes WHERE id = \$1`).
+				mock.ExpectExec(`UPDATE quotes SET deleted_at = now\(\) WHERE id = \$1 AND deleted_at IS NULL`).
 					WithArgs(args.quoteID).
 					WillReturnError(errors.ErrExecDB)
 			},
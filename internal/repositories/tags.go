@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// tagsOf selects the sorted tag slugs of the quote whose id is the SQL expression id.
+func tagsOf(id string) string {
+	return "ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = " + id + " ORDER BY t.slug)"
+}
+
+// tagWrites are WITH items replacing the tags of the rows of the WITH item q
+// with the slugs in the text[] parameter param, creating the missing tags
+// unless q is empty. The no-op update makes the insert return the ids of
+// existing tags too.
+func tagWrites(param string) string {
+	return `
+		tag_ids AS (
+			INSERT INTO tags (slug)
+			SELECT unnest(` + param + `::text[])
+			WHERE EXISTS (SELECT 1 FROM q)
+			ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+			RETURNING id
+		), untagged AS (
+			DELETE FROM quote_tags
+			WHERE quote_id IN (SELECT id FROM q) AND tag_id NOT IN (SELECT id FROM tag_ids)
+		), tagged AS (
+			INSERT INTO quote_tags (quote_id, tag_id)
+			SELECT q.id, tag_ids.id FROM q, tag_ids
+			ON CONFLICT DO NOTHING
+		)`
+}
+
+// ErrUnknownTagMatch is returned for a tag matching mode that is not supported.
+var ErrUnknownTagMatch = errors.New(errors.CodeInvalidArgument, "unsupported tag matching mode")
+
+// addTagCondition keeps the quotes tagged with any or all of tags, which must be distinct slugs.
+func addTagCondition(b *queryBuilder, tags []string, match models.TagMatch) error {
+	tagged := "FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id AND t.slug = ANY(" + b.arg(tags) + ")"
+	switch match {
+	case "", models.TagMatchAny:
+		b.addWhere("EXISTS (SELECT 1 " + tagged + ")")
+	case models.TagMatchAll:
+		b.addWhere("(SELECT count(*) " + tagged + ") = " + b.arg(len(tags)))
+	default:
+		return ErrUnknownTagMatch
+	}
+	return nil
+}
+
+// tagCounts counts the live quotes of every tag, unused tags included.
+const tagCounts = `
+	SELECT t.slug, count(q.id) AS quote_count
+	FROM tags t
+	LEFT JOIN quote_tags qt ON qt.tag_id = t.id
+	LEFT JOIN quotes q ON q.id = qt.quote_id AND q.deleted_at IS NULL
+`
+
+// GetTags returns every tag with its usage count, the most used first.
+func (db *DB) GetTags(ctx context.Context) ([]models.Tag, error) {
+	db.Log.Debug("started getting tags DB")
+
+	query := tagCounts + ` GROUP BY t.slug ORDER BY quote_count DESC, t.slug`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query))
+
+	rows, err := db.Conn.Query(ctx, query)
+	if err != nil {
+		db.Log.Error("failed to fetch tags", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.Tag, &t.QuoteCount); err != nil {
+			db.Log.Error("failed to scan tag", "error", err)
+			return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+		}
+		tags = append(tags, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		db.Log.Error("error while iterating over rows", "error", err)
+		return nil, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+
+	db.Log.Debug("ended getting tags DB", "count", len(tags))
+	return tags, nil
+}
+
+// RenameTag changes the slug of a tag on every quote. Renaming onto an
+// existing tag fails with ErrTagExists, see MergeTags.
+func (db *DB) RenameTag(ctx context.Context, from, to string) (models.Tag, error) {
+	db.Log.Debug("started renaming tag DB", "from", from, "to", to)
+
+	query := `UPDATE tags SET slug = $2 WHERE slug = $1`
+
+	result, err := db.Conn.Exec(ctx, query, from, to)
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			db.Log.Warn("tag already exists", "tag", to)
+			return models.Tag{}, errors.ErrTagExists
+		}
+		db.Log.Error("failed to rename tag", "error", err)
+		return models.Tag{}, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		db.Log.Warn("no tag was found with the given slug", "tag", from)
+		return models.Tag{}, errors.ErrTagNotFound
+	}
+
+	db.Log.Debug("Finished renaming tag DB", "tag", to)
+	return db.tag(ctx, to)
+}
+
+// MergeTags moves every quote tagged from to the existing tag into and
+// deletes from.
+func (db *DB) MergeTags(ctx context.Context, from, into string) (models.Tag, error) {
+	db.Log.Debug("started merging tags DB", "from", from, "into", into)
+
+	// Deleting the source tag cascades to its quote_tags rows, which the
+	// insert has copied to the target first.
+	query := `
+		WITH moved AS (
+			INSERT INTO quote_tags (quote_id, tag_id)
+			SELECT qt.quote_id, target.id
+			FROM quote_tags qt
+			JOIN tags source ON source.id = qt.tag_id AND source.slug = $1
+			JOIN tags target ON target.slug = $2
+			ON CONFLICT DO NOTHING
+		)
+		DELETE FROM tags
+		WHERE slug = $1 AND EXISTS (SELECT 1 FROM tags WHERE slug = $2)
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "from", from, "into", into)
+
+	result, err := db.Conn.Exec(ctx, query, from, into)
+	if err != nil {
+		db.Log.Error("failed to merge tags", "error", err)
+		return models.Tag{}, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		db.Log.Warn("no tags were found with the given slugs", "from", from, "into", into)
+		return models.Tag{}, errors.ErrTagNotFound
+	}
+
+	db.Log.Debug("Finished merging tags DB", "tag", into)
+	return db.tag(ctx, into)
+}
+
+// tag returns a single tag with its usage count.
+func (db *DB) tag(ctx context.Context, slug string) (models.Tag, error) {
+	query := tagCounts + ` WHERE t.slug = $1 GROUP BY t.slug`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "tag", slug)
+
+	var t models.Tag
+	if err := db.Conn.QueryRow(ctx, query, slug).Scan(&t.Tag, &t.QuoteCount); err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return models.Tag{}, errors.ErrTagNotFound
+		}
+		db.Log.Error("failed to fetch tag", "error", err)
+		return models.Tag{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	return t, nil
+}
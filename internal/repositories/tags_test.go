@@ -0,0 +1,265 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func TestDB_GetQuotes_Tags(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		columns = `SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id ORDER BY t.slug), created_at, updated_at FROM quotes WHERE deleted_at IS NULL AND `
+		tagged  = `FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id AND t.slug = ANY($1)`
+	)
+	tags := []string{"life", "stoicism"}
+
+	testTable := []struct {
+		name      string
+		match     models.TagMatch
+		condition string
+		args      []any
+	}{
+		{"Default any", "", `EXISTS (SELECT 1 ` + tagged + `) ORDER BY id ASC LIMIT $2`, []any{tags, models.DefaultPageLimit + 1}},
+		{"Any", models.TagMatchAny, `EXISTS (SELECT 1 ` + tagged + `) ORDER BY id ASC LIMIT $2`, []any{tags, models.DefaultPageLimit + 1}},
+		{"All", models.TagMatchAll, `(SELECT count(*) ` + tagged + `) = $2 ORDER BY id ASC LIMIT $3`, []any{tags, len(tags), models.DefaultPageLimit + 1}},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			mock.ExpectQuery(regexp.QuoteMeta(columns + testCase.condition)).
+				WithArgs(testCase.args...).
+				WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+					AddRow(1, "Seneca", "Quote1", 1.0, 0, models.Source{}, "unknown", tags, time.Time{}, time.Time{}))
+
+			page, err := r.GetQuotes(context.Background(), models.QuoteFilter{Tags: tags, TagMatch: testCase.match})
+			require.NoError(t, err)
+			assert.Equal(t, []models.Quote{{ID: 1, Author: "Seneca", Quote: "Quote1", Weight: 1, Tags: tags, AttributionStatus: models.AttributionUnknown}}, page.Quotes)
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+
+	_, err = r.GetQuotes(context.Background(), models.QuoteFilter{Tags: tags, TagMatch: "most"})
+	assert.ErrorIs(t, err, repositories.ErrUnknownTagMatch)
+}
+
+func TestDB_GetTags(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const query = `SELECT t.slug, count(q.id) AS quote_count FROM tags t LEFT JOIN quote_tags qt ON qt.tag_id = t.id LEFT JOIN quotes q ON q.id = qt.quote_id AND q.deleted_at IS NULL GROUP BY t.slug ORDER BY quote_count DESC, t.slug`
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     []models.Tag
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnRows(pgxmock.NewRows([]string{"slug", "quote_count"}).
+						AddRow("stoicism", 12).
+						AddRow("unused", 0))
+			},
+			expected: []models.Tag{{Tag: "stoicism", QuoteCount: 12}, {Tag: "unused", QuoteCount: 0}},
+		},
+		{
+			name: "OK - No tags",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnRows(pgxmock.NewRows([]string{"slug", "quote_count"}))
+			},
+			expected: []models.Tag{},
+		},
+		{
+			name: "DB Error",
+			mockBehavior: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(errors.ErrQuery)
+			},
+			expectedErr: errors.ErrQuery,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.GetTags(context.Background())
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Tags mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_RenameTag(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		renameQuery = `UPDATE tags SET slug = $2 WHERE slug = $1`
+		tagQuery    = `LEFT JOIN quotes q ON q.id = qt.quote_id AND q.deleted_at IS NULL WHERE t.slug = $1 GROUP BY t.slug`
+	)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     models.Tag
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(renameQuery)).
+					WithArgs("stoic", "stoicism").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectQuery(regexp.QuoteMeta(tagQuery)).
+					WithArgs("stoicism").
+					WillReturnRows(pgxmock.NewRows([]string{"slug", "quote_count"}).AddRow("stoicism", 3))
+			},
+			expected: models.Tag{Tag: "stoicism", QuoteCount: 3},
+		},
+		{
+			name: "Unknown tag - ErrTagNotFound",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(renameQuery)).
+					WithArgs("stoic", "stoicism").
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			expectedErr: errors.ErrTagNotFound,
+		},
+		{
+			name: "Taken name - ErrTagExists",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(renameQuery)).
+					WithArgs("stoic", "stoicism").
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			expectedErr: errors.ErrTagExists,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.RenameTag(context.Background(), "stoic", "stoicism")
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Tag mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_MergeTags(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const (
+		mergeQuery = `INSERT INTO quote_tags (quote_id, tag_id) SELECT qt.quote_id, target.id FROM quote_tags qt JOIN tags source ON source.id = qt.tag_id AND source.slug = $1 JOIN tags target ON target.slug = $2 ON CONFLICT DO NOTHING ) DELETE FROM tags WHERE slug = $1 AND EXISTS (SELECT 1 FROM tags WHERE slug = $2)`
+		tagQuery   = `WHERE t.slug = $1 GROUP BY t.slug`
+	)
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expected     models.Tag
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(mergeQuery)).
+					WithArgs("stoic", "stoicism").
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+				mock.ExpectQuery(regexp.QuoteMeta(tagQuery)).
+					WithArgs("stoicism").
+					WillReturnRows(pgxmock.NewRows([]string{"slug", "quote_count"}).AddRow("stoicism", 5))
+			},
+			expected: models.Tag{Tag: "stoicism", QuoteCount: 5},
+		},
+		{
+			name: "Unknown tag - ErrTagNotFound",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(mergeQuery)).
+					WithArgs("stoic", "stoicism").
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			expectedErr: errors.ErrTagNotFound,
+		},
+		{
+			name: "DB Error",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(mergeQuery)).
+					WithArgs("stoic", "stoicism").
+					WillReturnError(errors.ErrExecDB)
+			},
+			expectedErr: errors.ErrExecDB,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actual, actualErr := r.MergeTags(context.Background(), "stoic", "stoicism")
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Tag mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+// RestoreQuote takes a quote out of the trash.
+func (db *DB) RestoreQuote(ctx context.Context, quoteID int) (models.Quote, error) {
+	db.Log.Debug("started restoring quote DB", "quote_id", quoteID)
+	var restored models.Quote
+
+	query := `
+		WITH q AS (
+			UPDATE quotes
+			SET deleted_at = NULL
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			RETURNING id, quote, author, weight, author_id, source, attribution_status, created_at, updated_at
+		), ` + revisionItem(models.RevisionRestore) + `
+		SELECT id, quote, author, weight, author_id, source, attribution_status, ` + tagsOf("q.id") + `, created_at, updated_at FROM q
+	`
+
+	db.Log.Debug("executing query", "query", strings.TrimSpace(query), "quote_id", quoteID)
+
+	err := db.Conn.QueryRow(ctx, query, quoteID).Scan(
+		&restored.ID,
+		&restored.Quote,
+		&restored.Author,
+		&restored.Weight,
+		&restored.AuthorID,
+		&restored.Source,
+		&restored.AttributionStatus,
+		&restored.Tags,
+		&restored.CreatedAt,
+		&restored.UpdatedAt,
+	)
+
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			db.Log.Warn("no quote was found in the trash with the given id", "id", quoteID)
+			return models.Quote{}, errors.ErrQuoteNotFound
+		}
+		db.Log.Error("failed to restore quote", "error", err)
+		return models.Quote{}, fmt.Errorf("%w: %w", errors.ErrQuery, err)
+	}
+	db.ids.invalidate()
+	db.weights.invalidate()
+
+	db.Log.Debug("Finished restoring quote DB", "quote_id", restored.ID)
+	return restored, nil
+}
+
+// PurgeQuote permanently deletes a quote from the trash. Live quotes have to
+// be deleted first.
+func (db *DB) PurgeQuote(ctx context.Context, quoteID int) error {
+	db.Log.Debug("started purging quote DB", "quote_id", quoteID)
+
+	query := `DELETE FROM quotes WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := db.Conn.Exec(ctx, query, quoteID)
+	if err != nil {
+		db.Log.Error("failed to purge quote", "error", err)
+		return fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		db.Log.Warn("no quote was found in the trash with the given id", "id", quoteID)
+		return errors.ErrQuoteNotFound
+	}
+
+	db.Log.Debug("Finished purging quote DB", "quote_id", quoteID)
+	return nil
+}
+
+// PurgeTrash permanently deletes the quotes that were moved to the trash
+// more than olderThan ago and returns how many were deleted.
+func (db *DB) PurgeTrash(ctx context.Context, olderThan time.Duration) (int64, error) {
+	db.Log.Debug("started purging trash DB", "older_than", olderThan)
+
+	query := `DELETE FROM quotes WHERE deleted_at < now() - make_interval(secs => $1)`
+
+	result, err := db.Conn.Exec(ctx, query, olderThan.Seconds())
+	if err != nil {
+		db.Log.Error("failed to purge trash", "error", err)
+		return 0, fmt.Errorf("%w: %w", errors.ErrExecDB, err)
+	}
+
+	db.Log.Debug("Finished purging trash DB", "purged", result.RowsAffected())
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,189 @@
+package repositories_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/repositories"
+	"quotemanager/pkg/errors"
+)
+
+func TestDB_GetQuotes_Trashed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	deletedAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, author, quote, weight, author_id, source, attribution_status, ARRAY(SELECT t.slug FROM quote_tags qt JOIN tags t ON t.id = qt.tag_id WHERE qt.quote_id = quotes.id ORDER BY t.slug), created_at, updated_at, deleted_at FROM quotes WHERE deleted_at IS NOT NULL ORDER BY id ASC LIMIT $1`)).
+		WithArgs(models.DefaultPageLimit + 1).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "author", "quote", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at", "deleted_at"}).
+			AddRow(3, "Author3", "Quote3", 1.0, 0, models.Source{}, "unknown", []string{"stoicism"}, time.Time{}, time.Time{}, &deletedAt))
+
+	page, err := r.GetQuotes(context.Background(), models.QuoteFilter{Trashed: true})
+	require.NoError(t, err)
+	assert.Equal(t, []models.Quote{{ID: 3, Author: "Author3", Quote: "Quote3", Weight: 1, Tags: []string{"stoicism"}, DeletedAt: &deletedAt, AttributionStatus: models.AttributionUnknown}}, page.Quotes)
+	assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+}
+
+func TestDB_RestoreQuote(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const query = `UPDATE quotes SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL RETURNING id, quote, author, weight, author_id, source, attribution_status, created_at, updated_at`
+
+	testTable := []struct {
+		name         string
+		quoteID      int
+		mockBehavior func(quoteID int)
+		expected     models.Quote
+		expectedErr  error
+	}{
+		{
+			name:    "OK",
+			quoteID: 1,
+			mockBehavior: func(quoteID int) {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(quoteID).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "quote", "author", "weight", "author_id", "source", "attribution_status", "tags", "created_at", "updated_at"}).
+						AddRow(1, "Quote1", "Author1", 1.0, 0, models.Source{}, "unknown", []string{}, time.Time{}, time.Time{}))
+			},
+			expected: models.Quote{ID: 1, Quote: "Quote1", Author: "Author1", Weight: 1, Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+		},
+		{
+			name:    "Not in the trash - ErrQuoteNotFound",
+			quoteID: 42,
+			mockBehavior: func(quoteID int) {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(quoteID).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+		{
+			name:    "DB Error",
+			quoteID: 1,
+			mockBehavior: func(quoteID int) {
+				mock.ExpectQuery(regexp.QuoteMeta(query)).
+					WithArgs(quoteID).
+					WillReturnError(errors.ErrQuery)
+			},
+			expectedErr: errors.ErrQuery,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior(testCase.quoteID)
+
+			actual, actualErr := r.RestoreQuote(context.Background(), testCase.quoteID)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.Equal(t, testCase.expected, actual, "Quote data mismatch")
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_PurgeQuote(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	const query = `DELETE FROM quotes WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	testTable := []struct {
+		name         string
+		mockBehavior func()
+		expectedErr  error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "Not in the trash - ErrQuoteNotFound",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			expectedErr: errors.ErrQuoteNotFound,
+		},
+		{
+			name: "DB Error",
+			mockBehavior: func() {
+				mock.ExpectExec(regexp.QuoteMeta(query)).
+					WithArgs(1).
+					WillReturnError(errors.ErrExecDB)
+			},
+			expectedErr: errors.ErrExecDB,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.mockBehavior()
+
+			actualErr := r.PurgeQuote(context.Background(), 1)
+
+			if testCase.expectedErr != nil {
+				assert.ErrorIs(t, actualErr, testCase.expectedErr)
+			} else {
+				assert.NoError(t, actualErr, "Did not expect an error")
+			}
+			assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+		})
+	}
+}
+
+func TestDB_PurgeTrash(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repositories.DB{
+		Log:  newTestLogger(),
+		Conn: mock,
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM quotes WHERE deleted_at < now() - make_interval(secs => $1)`)).
+		WithArgs(float64(30 * 24 * 60 * 60)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 4))
+
+	purged, err := r.PurgeTrash(context.Background(), 30*24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), purged)
+	assert.NoError(t, mock.ExpectationsWereMet(), "mock expectations not met")
+}
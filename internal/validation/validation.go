@@ -0,0 +1,438 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"quotemanager/internal/models"
+	"quotemanager/pkg/errors"
+)
+
+const (
+	// MaxAuthorLength is the maximum author length in characters.
+	MaxAuthorLength = 256
+	// MaxQuoteLength is the maximum quote length in characters.
+	MaxQuoteLength = 4096
+	// MaxTagLength is the maximum tag slug length in characters.
+	MaxTagLength = 64
+	// MaxQuoteTags is the maximum number of tags of a quote.
+	MaxQuoteTags = 20
+	// MaxAuthorAliases is the maximum number of aliases of an author.
+	MaxAuthorAliases = 20
+	// MaxBioLength is the maximum author biography length in characters.
+	MaxBioLength = 2000
+	// MaxNationalityLength is the maximum author nationality length in characters.
+	MaxNationalityLength = 64
+	// MaxSourceTitleLength is the maximum length of a source title in characters.
+	MaxSourceTitleLength = 512
+	// MaxSourcePageLength is the maximum length of a source page in characters.
+	MaxSourcePageLength = 32
+	// MaxSourceURLLength is the maximum length of a source URL in characters.
+	MaxSourceURLLength = 2048
+	// MaxBodyBytes limits the size of any JSON request body.
+	MaxBodyBytes = 64 << 10
+)
+
+var (
+	ErrBodyTooLarge  = errors.New(errors.CodePayloadTooLarge, fmt.Sprintf("request body is larger than %d bytes", MaxBodyBytes))
+	ErrMalformedBody = errors.New(errors.CodeInvalidArgument, "request body is not valid JSON")
+)
+
+// Errors collects field problems. A nil Errors means the input is valid.
+type Errors []errors.FieldError
+
+// Add appends a problem for field.
+func (e *Errors) Add(field, message string) {
+	*e = append(*e, errors.FieldError{Field: field, Message: message})
+}
+
+// OrNil returns nil for an empty list and a CodeValidation error otherwise.
+func (e Errors) OrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return errors.Validation(e)
+}
+
+// DecodeJSON decodes exactly one JSON value from the request body into dst.
+// Bodies over MaxBodyBytes yield ErrBodyTooLarge, unknown or mistyped fields
+// yield a validation error and any other syntax problem or trailing data
+// yields ErrMalformedBody.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	// The body is read whole, so that the path of an unknown field can be
+	// looked up in it.
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if stdErrors.As(err, &maxBytesErr) {
+			return ErrBodyTooLarge
+		}
+		return fmt.Errorf("%w: %w", ErrMalformedBody, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err, data, reflect.TypeOf(dst))
+	}
+
+	if err := dec.Decode(&struct{}{}); !stdErrors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: unexpected data after the JSON value", ErrMalformedBody)
+	}
+
+	return nil
+}
+
+func decodeError(err error, data []byte, typ reflect.Type) error {
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case stdErrors.As(err, &typeErr) && typeErr.Field != "":
+		return Errors{{Field: typeErr.Field, Message: "must be of type " + jsonTypeName(typeErr.Type.Kind())}}.OrNil()
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		if path, ok := unknownFieldPath(data, typ, field); ok {
+			field = path
+		}
+		return Errors{{Field: field, Message: "unknown field"}}.OrNil()
+	default:
+		return fmt.Errorf("%w: %w", ErrMalformedBody, err)
+	}
+}
+
+// unknownFieldPath looks up the dotted path of a member called name of the
+// JSON value data that typ has no field for, as the decoder only reports the
+// name. Array elements add nothing to the path, like in the Field of
+// json.UnmarshalTypeError.
+func unknownFieldPath(data []byte, typ reflect.Type, name string) (string, bool) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		var members map[string]json.RawMessage
+		if json.Unmarshal(data, &members) != nil {
+			return "", false
+		}
+		for _, key := range slices.Sorted(maps.Keys(members)) {
+			field, ok := jsonField(typ, key)
+			if !ok {
+				if key == name {
+					return key, true
+				}
+				continue
+			}
+			if path, ok := unknownFieldPath(members[key], field.Type, name); ok {
+				return key + "." + path, true
+			}
+		}
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if json.Unmarshal(data, &values) != nil {
+			return "", false
+		}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			if path, ok := unknownFieldPath(values[key], typ.Elem(), name); ok {
+				return key + "." + path, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return "", false
+		}
+		for _, item := range items {
+			if path, ok := unknownFieldPath(item, typ.Elem(), name); ok {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsonField returns the field of the struct type typ that the member key is
+// decoded into, matching names like encoding/json: exactly, or else
+// ignoring case.
+func jsonField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	var (
+		folded reflect.StructField
+		found  bool
+	)
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if inner, ok := jsonField(embedded, key); ok {
+					return inner, true
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if name == key {
+			return field, true
+		}
+		if !found && strings.EqualFold(name, key) {
+			folded, found = field, true
+		}
+	}
+	return folded, found
+}
+
+// jsonTypeName names the JSON type a Go value of the given kind is decoded
+// from. Pointers never show up, the decoder reports the type they point to.
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return kind.String()
+	}
+}
+
+// Quote normalizes the author and quote text in place and validates them.
+func Quote(q *models.Quote) error {
+	var errs Errors
+	q.Author = normalizeField(&errs, "author", q.Author, MaxAuthorLength, false)
+	q.Quote = normalizeField(&errs, "quote", q.Quote, MaxQuoteLength, true)
+	checkWeight(&errs, q.Weight)
+	q.Tags = normalizeTags(&errs, q.Tags)
+	q.Source = normalizeSource(&errs, q.Source)
+	if q.AttributionStatus == "" {
+		q.AttributionStatus = models.DefaultAttributionStatus
+	}
+	checkAttributionStatus(&errs, q.AttributionStatus)
+	return errs.OrNil()
+}
+
+// QuotePatch normalizes and validates the fields present in a merge patch.
+func QuotePatch(p *models.QuotePatch) error {
+	var errs Errors
+	if p.Author != nil {
+		author := normalizeField(&errs, "author", *p.Author, MaxAuthorLength, false)
+		p.Author = &author
+	}
+	if p.Quote != nil {
+		quote := normalizeField(&errs, "quote", *p.Quote, MaxQuoteLength, true)
+		p.Quote = &quote
+	}
+	if p.Weight != nil {
+		checkWeight(&errs, *p.Weight)
+	}
+	if p.Tags != nil {
+		tags := normalizeTags(&errs, *p.Tags)
+		p.Tags = &tags
+	}
+	if p.Source != nil {
+		p.Source.Source = normalizeSource(&errs, p.Source.Source)
+	}
+	if p.AttributionStatus != nil {
+		checkAttributionStatus(&errs, *p.AttributionStatus)
+	}
+	return errs.OrNil()
+}
+
+func checkWeight(errs *Errors, weight float64) {
+	if weight < 0 || weight > models.MaxQuoteWeight {
+		errs.Add("weight", fmt.Sprintf("must be between 0 and %d", models.MaxQuoteWeight))
+	}
+}
+
+// Author normalizes the fields of an author in place and validates them.
+// Aliases are deduplicated case-insensitively, sorted and never contain the
+// name itself. Years before the common era are negative.
+func Author(a *models.Author) error {
+	var errs Errors
+	a.Name = normalizeField(&errs, "name", a.Name, MaxAuthorLength, false)
+
+	aliases := make([]string, 0, len(a.Aliases))
+	seen := map[string]bool{strings.ToLower(a.Name): true}
+	for i, alias := range a.Aliases {
+		alias = normalizeField(&errs, fmt.Sprintf("aliases[%d]", i), alias, MaxAuthorLength, false)
+		if key := strings.ToLower(alias); !seen[key] {
+			seen[key] = true
+			aliases = append(aliases, alias)
+		}
+	}
+	slices.Sort(aliases)
+	if len(aliases) > MaxAuthorAliases {
+		errs.Add("aliases", fmt.Sprintf("must have at most %d distinct aliases, got %d", MaxAuthorAliases, len(aliases)))
+	}
+	a.Aliases = aliases
+
+	checkYear(&errs, "birth_year", a.BirthYear)
+	checkYear(&errs, "death_year", a.DeathYear)
+	if a.BirthYear != nil && a.DeathYear != nil && *a.BirthYear > *a.DeathYear {
+		errs.Add("death_year", "must not be before birth_year")
+	}
+
+	a.Bio = optionalField(&errs, "bio", a.Bio, MaxBioLength, true)
+	a.Nationality = optionalField(&errs, "nationality", a.Nationality, MaxNationalityLength, false)
+
+	return errs.OrNil()
+}
+
+// Tag turns a tag into its slug: the lowercase letters and digits of any
+// script, with every run of other characters replaced by a single hyphen.
+// The result is empty when the tag has no letters or digits.
+func Tag(tag string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFC.String(tag) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
+
+// CheckTag reports what is wrong with a slug returned by Tag, or "" when it is valid.
+func CheckTag(slug string) string {
+	if slug == "" {
+		return "must contain a letter or a digit"
+	}
+	if length := utf8.RuneCountInString(slug); length > MaxTagLength {
+		return fmt.Sprintf("must be at most %d characters long, got %d", MaxTagLength, length)
+	}
+	return ""
+}
+
+// normalizeTags turns tags into sorted, distinct slugs. It never returns nil
+// so a quote without tags is encoded as an empty list.
+func normalizeTags(errs *Errors, tags []string) []string {
+	slugs := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		slug := Tag(tag)
+		if problem := CheckTag(slug); problem != "" {
+			errs.Add(fmt.Sprintf("tags[%d]", i), problem)
+			continue
+		}
+		slugs = append(slugs, slug)
+	}
+	slices.Sort(slugs)
+	slugs = slices.Compact(slugs)
+
+	if len(slugs) > MaxQuoteTags {
+		errs.Add("tags", fmt.Sprintf("must have at most %d distinct tags, got %d", MaxQuoteTags, len(slugs)))
+	}
+	return slugs
+}
+
+// normalizeSource normalizes the members of a source, all of them optional.
+func normalizeSource(errs *Errors, s models.Source) models.Source {
+	s.Title = optionalField(errs, "source.title", s.Title, MaxSourceTitleLength, false)
+	checkYear(errs, "source.year", s.Year)
+	s.Page = optionalField(errs, "source.page", s.Page, MaxSourcePageLength, false)
+	s.Translator = optionalField(errs, "source.translator", s.Translator, MaxAuthorLength, false)
+	s.OriginalText = optionalField(errs, "source.original_text", s.OriginalText, MaxQuoteLength, true)
+
+	s.URL = optionalField(errs, "source.url", s.URL, MaxSourceURLLength, false)
+	if s.URL != "" {
+		if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.Add("source.url", "must be an absolute http or https URL")
+		}
+	}
+	return s
+}
+
+func checkAttributionStatus(errs *Errors, status models.AttributionStatus) {
+	if !models.AttributionStatuses[status] {
+		errs.Add("attribution_status", "must be one of verified, disputed, misattributed, unknown")
+	}
+}
+
+// checkYear rejects years in the future, years before the common era are negative.
+func checkYear(errs *Errors, field string, year *int) {
+	if year != nil && *year > time.Now().Year() {
+		errs.Add(field, "must not be in the future")
+	}
+}
+
+// optionalField is normalizeField for a field that may be left empty.
+func optionalField(errs *Errors, field, value string, maxLength int, multiline bool) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	return normalizeField(errs, field, value, maxLength, multiline)
+}
+
+// normalizeField trims surrounding whitespace, converts the value to Unicode NFC
+// and checks that it is present, short enough and free of control characters.
+// Line breaks and tabs are allowed only in multiline fields.
+func normalizeField(errs *Errors, field, value string, maxLength int, multiline bool) string {
+	if !utf8.ValidString(value) {
+		errs.Add(field, "must be valid UTF-8")
+		return value
+	}
+
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = norm.NFC.String(strings.TrimSpace(value))
+
+	if value == "" {
+		errs.Add(field, "is required")
+		return value
+	}
+
+	if length := utf8.RuneCountInString(value); length > maxLength {
+		errs.Add(field, fmt.Sprintf("must be at most %d characters long, got %d", maxLength, length))
+	}
+
+	for _, r := range value {
+		if multiline && (r == '\n' || r == '\t') {
+			continue
+		}
+		if unicode.IsControl(r) {
+			errs.Add(field, "must not contain control characters")
+			break
+		}
+	}
+
+	return value
+}
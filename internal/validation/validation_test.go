@@ -0,0 +1,223 @@
+package validation_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"quotemanager/internal/models"
+	"quotemanager/internal/validation"
+	"quotemanager/pkg/errors"
+)
+
+func TestQuote(t *testing.T) {
+	testTable := []struct {
+		name       string
+		quote      models.Quote
+		expected   models.Quote
+		wantFields []string
+	}{
+		{
+			name:     "OK - Trimmed and NFC normalized",
+			quote:    models.Quote{Author: "  Rene\u0301 Descartes ", Quote: "\tI think, therefore I am.\r\n"},
+			expected: models.Quote{Author: "Ren\u00e9 Descartes", Quote: "I think, therefore I am.", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+		},
+		{
+			name:     "OK - Multiline quote",
+			quote:    models.Quote{Author: "Basho", Quote: "An old silent pond\nA frog jumps into the pond"},
+			expected: models.Quote{Author: "Basho", Quote: "An old silent pond\nA frog jumps into the pond", Tags: []string{}, AttributionStatus: models.AttributionUnknown},
+		},
+		{
+			name:     "OK - Tags slugged, sorted and deduplicated",
+			quote:    models.Quote{Author: "Seneca", Quote: "ok", Tags: []string{"Stoicism", " Life  Advice! ", "stoicism", "Время"}},
+			expected: models.Quote{Author: "Seneca", Quote: "ok", Tags: []string{"life-advice", "stoicism", "время"}, AttributionStatus: models.AttributionUnknown},
+		},
+		{
+			name: "OK - Source trimmed, status kept",
+			quote: models.Quote{Author: "Seneca", Quote: "ok", AttributionStatus: models.AttributionDisputed,
+				Source: models.Source{Title: " Letters from a Stoic ", Year: ptr(65), Page: "  ", URL: "https://example.com/letters"}},
+			expected: models.Quote{Author: "Seneca", Quote: "ok", Tags: []string{}, AttributionStatus: models.AttributionDisputed,
+				Source: models.Source{Title: "Letters from a Stoic", Year: ptr(65), URL: "https://example.com/letters"}},
+		},
+		{
+			name:       "Empty fields",
+			quote:      models.Quote{Author: "   ", Quote: ""},
+			wantFields: []string{"author", "quote"},
+		},
+		{
+			name:       "Control characters",
+			quote:      models.Quote{Author: "Bad\nAuthor", Quote: "Bell\x07"},
+			wantFields: []string{"author", "quote"},
+		},
+		{
+			name:       "Too long",
+			quote:      models.Quote{Author: strings.Repeat("a", validation.MaxAuthorLength+1), Quote: "ok"},
+			wantFields: []string{"author"},
+		},
+		{
+			name:       "Weight out of range",
+			quote:      models.Quote{Author: "Seneca", Quote: "ok", Weight: -1},
+			wantFields: []string{"weight"},
+		},
+		{
+			name: "Invalid source and status",
+			quote: models.Quote{Author: "Seneca", Quote: "ok", AttributionStatus: "apocryphal",
+				Source: models.Source{Year: ptr(time.Now().Year() + 1), URL: "example.com/letters", Translator: "Bad\nTranslator"}},
+			wantFields: []string{"source.year", "source.translator", "source.url", "attribution_status"},
+		},
+		{
+			name:       "Tag without letters or digits",
+			quote:      models.Quote{Author: "Seneca", Quote: "ok", Tags: []string{"life", "!!!"}},
+			wantFields: []string{"tags[1]"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			quote := testCase.quote
+			err := validation.Quote(&quote)
+
+			if len(testCase.wantFields) == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expected, quote)
+				return
+			}
+
+			appErr, ok := errors.As(err)
+			require.True(t, ok, "expected a typed error, got %v", err)
+			assert.Equal(t, errors.CodeValidation, appErr.Code)
+			var fields []string
+			for _, fe := range appErr.Fields {
+				fields = append(fields, fe.Field)
+			}
+			assert.Equal(t, testCase.wantFields, fields)
+		})
+	}
+}
+
+func TestAuthor(t *testing.T) {
+	testTable := []struct {
+		name       string
+		author     models.Author
+		expected   models.Author
+		wantFields []string
+	}{
+		{
+			name:     "OK - Aliases deduplicated without the name",
+			author:   models.Author{Name: " Confucius ", Aliases: []string{"Kong Fuzi", "confucius", "kong fuzi", "Kongzi"}, Bio: "  "},
+			expected: models.Author{Name: "Confucius", Aliases: []string{"Kong Fuzi", "Kongzi"}},
+		},
+		{
+			name:     "OK - Years before the common era",
+			author:   models.Author{Name: "Confucius", BirthYear: ptr(-551), DeathYear: ptr(-479)},
+			expected: models.Author{Name: "Confucius", Aliases: []string{}, BirthYear: ptr(-551), DeathYear: ptr(-479)},
+		},
+		{
+			name:       "Missing name and empty alias",
+			author:     models.Author{Name: "", Aliases: []string{" "}},
+			wantFields: []string{"name", "aliases[0]"},
+		},
+		{
+			name:       "Death before birth",
+			author:     models.Author{Name: "Seneca", BirthYear: ptr(65), DeathYear: ptr(4)},
+			wantFields: []string{"death_year"},
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			author := testCase.author
+			err := validation.Author(&author)
+
+			if len(testCase.wantFields) == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expected, author)
+				return
+			}
+
+			appErr, ok := errors.As(err)
+			require.True(t, ok, "expected a typed error, got %v", err)
+			var fields []string
+			for _, fe := range appErr.Fields {
+				fields = append(fields, fe.Field)
+			}
+			assert.Equal(t, testCase.wantFields, fields)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type request struct {
+		Author    string            `json:"author"`
+		Weight    *float64          `json:"weight"`
+		Count     uint8             `json:"count"`
+		Rank      int32             `json:"rank"`
+		Published bool              `json:"published"`
+		Tags      []string          `json:"tags"`
+		Source    models.Source     `json:"source"`
+		Sources   []models.Source   `json:"sources"`
+		Labels    map[string]string `json:"labels"`
+	}
+
+	testTable := []struct {
+		name        string
+		body        string
+		wantErrIs   error
+		wantField   string
+		wantMessage string
+	}{
+		{name: "OK", body: `{"author":"Seneca"}`},
+		{name: "Unknown field", body: `{"author":"Seneca","year":65}`, wantField: "year", wantMessage: "unknown field"},
+		{name: "Unknown field - Nested", body: `{"author":"Seneca","source":{"title":"Letters","pgae":"12"}}`, wantField: "source.pgae", wantMessage: "unknown field"},
+		{name: "Unknown field - Nested with folded case", body: `{"Source":{"pgae":"12"}}`, wantField: "Source.pgae", wantMessage: "unknown field"},
+		{name: "Unknown field - In array", body: `{"sources":[{"title":"Letters"},{"isbn":"123"}]}`, wantField: "sources.isbn", wantMessage: "unknown field"},
+		{name: "Wrong type", body: `{"author":42}`, wantField: "author", wantMessage: "must be of type string"},
+		{name: "Wrong type - Pointer", body: `{"weight":"heavy"}`, wantField: "weight", wantMessage: "must be of type number"},
+		{name: "Wrong type - Unsigned", body: `{"count":"3"}`, wantField: "count", wantMessage: "must be of type number"},
+		{name: "Wrong type - Sized int", body: `{"rank":true}`, wantField: "rank", wantMessage: "must be of type number"},
+		{name: "Wrong type - Bool", body: `{"published":"yes"}`, wantField: "published", wantMessage: "must be of type boolean"},
+		{name: "Wrong type - Slice", body: `{"tags":"life"}`, wantField: "tags", wantMessage: "must be of type array"},
+		{name: "Wrong type - Struct", body: `{"source":"x"}`, wantField: "source", wantMessage: "must be of type object"},
+		{name: "Wrong type - Map", body: `{"labels":[]}`, wantField: "labels", wantMessage: "must be of type object"},
+		{name: "Wrong type - Nested", body: `{"source":{"year":"65"}}`, wantField: "source.year", wantMessage: "must be of type number"},
+		{name: "Trailing data", body: `{"author":"Seneca"} {}`, wantErrIs: validation.ErrMalformedBody},
+		{name: "Syntax error", body: `{"author":`, wantErrIs: validation.ErrMalformedBody},
+		{
+			name:      "Too large",
+			body:      `{"author":"` + strings.Repeat("a", validation.MaxBodyBytes) + `"}`,
+			wantErrIs: validation.ErrBodyTooLarge,
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/quotes", strings.NewReader(testCase.body))
+			w := httptest.NewRecorder()
+
+			var dst request
+			err := validation.DecodeJSON(w, r, &dst)
+
+			switch {
+			case testCase.wantErrIs != nil:
+				assert.ErrorIs(t, err, testCase.wantErrIs)
+			case testCase.wantField != "":
+				appErr, ok := errors.As(err)
+				require.True(t, ok, "expected a typed error, got %v", err)
+				assert.Equal(t, errors.CodeValidation, appErr.Code)
+				assert.Equal(t, testCase.wantField, appErr.Fields[0].Field)
+				assert.Equal(t, testCase.wantMessage, appErr.Fields[0].Message)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1 +1,2 @@
-DROP TABLE IF EXISTS quotes;
\ No newline at end of file
+DROP INDEX IF EXISTS idx_author;
+DROP TABLE IF EXISTS quotes;
@@ -4,4 +4,4 @@ CREATE TABLE IF NOT EXISTS quotes (
     quote TEXT NOT NULL
 );
 
-CREATE INDEX idx_author ON quotes(author);
\ No newline at end of file
+CREATE INDEX idx_author ON quotes(author);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_quotes_length;
+DROP INDEX IF EXISTS idx_quotes_created_at;
+
+ALTER TABLE quotes
+    DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,5 @@
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS idx_quotes_created_at ON quotes (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_quotes_length ON quotes (char_length(quote), id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_quotes_search_russian;
+DROP INDEX IF EXISTS idx_quotes_search_english;
+
+ALTER TABLE quotes
+    DROP COLUMN IF EXISTS search_russian,
+    DROP COLUMN IF EXISTS search_english;
@@ -0,0 +1,8 @@
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS search_english tsvector
+        GENERATED ALWAYS AS (to_tsvector('english'::regconfig, quote)) STORED,
+    ADD COLUMN IF NOT EXISTS search_russian tsvector
+        GENERATED ALWAYS AS (to_tsvector('russian'::regconfig, quote)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_quotes_search_english ON quotes USING GIN (search_english);
+CREATE INDEX IF NOT EXISTS idx_quotes_search_russian ON quotes USING GIN (search_russian);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_quotes_author_trgm;
+DROP INDEX IF EXISTS idx_quotes_author_lower;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- text_pattern_ops serves both case-insensitive equality and prefix LIKE
+-- regardless of the database collation.
+CREATE INDEX IF NOT EXISTS idx_quotes_author_lower ON quotes (lower(author) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_quotes_author_trgm ON quotes USING GIN (author gin_trgm_ops);
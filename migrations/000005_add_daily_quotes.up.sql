@@ -0,0 +1,12 @@
+-- One row per time zone and calendar day. The primary key lets concurrent
+-- replicas race on the insert and agree on whichever row wins.
+CREATE TABLE IF NOT EXISTS daily_quotes (
+    tz         TEXT        NOT NULL,
+    day        DATE        NOT NULL,
+    quote_id   BIGINT      NOT NULL REFERENCES quotes (id) ON DELETE CASCADE,
+    cycle      INTEGER     NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tz, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_quotes_cycle ON daily_quotes (tz, cycle, quote_id);
@@ -0,0 +1,14 @@
+-- The rotation of a client over a filtered pool: a shuffled list of the
+-- matching quote ids served in order, so that random requests carrying the
+-- client token do not repeat until the pool is exhausted. served is the
+-- number of ids already served.
+CREATE TABLE IF NOT EXISTS random_rotations (
+    client     TEXT        NOT NULL,
+    pool       TEXT        NOT NULL,
+    ids        BIGINT[]    NOT NULL,
+    served     INTEGER     NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (client, pool)
+);
+
+CREATE INDEX IF NOT EXISTS idx_random_rotations_expires_at ON random_rotations (expires_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_quotes_weight;
+DROP INDEX IF EXISTS idx_quotes_weighted;
+
+ALTER TABLE quotes DROP COLUMN IF EXISTS weight;
@@ -0,0 +1,10 @@
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS weight DOUBLE PRECISION NOT NULL DEFAULT 1
+        CONSTRAINT quotes_weight_check CHECK (weight >= 0);
+
+-- Covers the (id, weight) scans of weighted random sampling, quotes with a
+-- zero weight are never drawn.
+CREATE INDEX IF NOT EXISTS idx_quotes_weighted ON quotes (id, weight) WHERE weight > 0;
+
+-- Serves sort=popularity, which orders by the weight.
+CREATE INDEX IF NOT EXISTS idx_quotes_weight ON quotes (weight, id);
@@ -0,0 +1,6 @@
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Rows that existed before the column was added were last changed no
+-- earlier than they were created.
+UPDATE quotes SET updated_at = created_at;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_quotes_weighted;
+CREATE INDEX IF NOT EXISTS idx_quotes_weighted ON quotes (id, weight) WHERE weight > 0;
+
+DROP INDEX IF EXISTS idx_quotes_deleted_at;
+
+-- Quotes in the trash were deleted as far as the previous schema is concerned.
+DELETE FROM quotes WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE quotes DROP COLUMN IF EXISTS deleted_at;
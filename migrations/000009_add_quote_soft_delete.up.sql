@@ -0,0 +1,9 @@
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Serves the trash listing and age-based purges.
+CREATE INDEX IF NOT EXISTS idx_quotes_deleted_at ON quotes (deleted_at, id) WHERE deleted_at IS NOT NULL;
+
+-- Weighted sampling only ever scans live quotes.
+DROP INDEX IF EXISTS idx_quotes_weighted;
+CREATE INDEX IF NOT EXISTS idx_quotes_weighted ON quotes (id, weight) WHERE weight > 0 AND deleted_at IS NULL;
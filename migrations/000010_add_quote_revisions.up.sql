@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS quote_revisions (
+    id         BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    quote_id   BIGINT           NOT NULL REFERENCES quotes (id) ON DELETE CASCADE,
+    operation  TEXT             NOT NULL CHECK (operation IN ('create', 'update', 'delete', 'restore', 'revert')),
+    author     TEXT             NOT NULL,
+    quote      TEXT             NOT NULL,
+    weight     DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ      NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_revisions_quote_id ON quote_revisions (quote_id, id);
+
+-- Existing quotes start their history with their current state.
+INSERT INTO quote_revisions (quote_id, operation, author, quote, weight, created_at)
+SELECT id, 'create', author, quote, weight, created_at
+FROM quotes
+ORDER BY id;
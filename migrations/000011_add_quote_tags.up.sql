@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id   BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+    slug TEXT NOT NULL UNIQUE CHECK (slug <> '' AND slug = lower(slug))
+);
+
+CREATE TABLE IF NOT EXISTS quote_tags (
+    quote_id BIGINT NOT NULL REFERENCES quotes (id) ON DELETE CASCADE,
+    tag_id   BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (quote_id, tag_id)
+);
+
+-- Serves the tag filters, the usage counts and merges.
+CREATE INDEX IF NOT EXISTS idx_quote_tags_tag_id ON quote_tags (tag_id, quote_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_quotes_author_id;
+ALTER TABLE quotes DROP COLUMN IF EXISTS author_id;
+
+DROP TABLE IF EXISTS author_aliases;
+DROP TABLE IF EXISTS authors;
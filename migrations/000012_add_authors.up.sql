@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS authors (
+    id          BIGINT PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    name        TEXT        NOT NULL,
+    birth_year  INTEGER,
+    death_year  INTEGER,
+    bio         TEXT        NOT NULL DEFAULT '',
+    nationality TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT authors_years_check CHECK (birth_year IS NULL OR death_year IS NULL OR birth_year <= death_year)
+);
+
+-- Names and aliases identify an author regardless of case.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_authors_name_lower ON authors (lower(name));
+
+CREATE TABLE IF NOT EXISTS author_aliases (
+    author_id BIGINT NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+    alias     TEXT   NOT NULL,
+    PRIMARY KEY (author_id, alias)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_author_aliases_alias_lower ON author_aliases (lower(alias));
+
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS author_id BIGINT REFERENCES authors (id);
+
+-- Every spelling of a name that only differs in case becomes one author,
+-- named after the spelling most quotes use.
+INSERT INTO authors (name)
+SELECT DISTINCT ON (lower(author)) author
+FROM quotes
+GROUP BY author
+ORDER BY lower(author), count(*) DESC, author;
+
+UPDATE quotes
+SET author = authors.name, author_id = authors.id
+FROM authors
+WHERE lower(authors.name) = lower(quotes.author);
+
+ALTER TABLE quotes
+    ALTER COLUMN author_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_quotes_author_id ON quotes (author_id, id);
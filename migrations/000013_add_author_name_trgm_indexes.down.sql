@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_author_aliases_alias_trgm;
+DROP INDEX IF EXISTS idx_authors_name_trgm;
@@ -0,0 +1,7 @@
+-- Serve the trigram similarity (%) lookups of duplicate authors on the
+-- normalized names, see normalizedName. The expressions must stay identical
+-- to the ones the queries use.
+CREATE INDEX IF NOT EXISTS idx_authors_name_trgm
+    ON authors USING GIN ((trim(regexp_replace(lower(name), '[^[:alnum:]]+', ' ', 'g'))) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_author_aliases_alias_trgm
+    ON author_aliases USING GIN ((trim(regexp_replace(lower(alias), '[^[:alnum:]]+', ' ', 'g'))) gin_trgm_ops);
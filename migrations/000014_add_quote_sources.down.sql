@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_quotes_attribution_status;
+
+ALTER TABLE quotes DROP COLUMN IF EXISTS attribution_status;
+ALTER TABLE quotes DROP COLUMN IF EXISTS source;
@@ -0,0 +1,11 @@
+-- The source is optional citation metadata, see models.Source for its members.
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS source JSONB NOT NULL DEFAULT '{}'
+        CONSTRAINT quotes_source_check CHECK (jsonb_typeof(source) = 'object');
+
+ALTER TABLE quotes
+    ADD COLUMN IF NOT EXISTS attribution_status TEXT NOT NULL DEFAULT 'unknown'
+        CONSTRAINT quotes_attribution_status_check CHECK (attribution_status IN ('verified', 'disputed', 'misattributed', 'unknown'));
+
+-- Covers the attribution_status filter of the quote list in id order.
+CREATE INDEX IF NOT EXISTS idx_quotes_attribution_status ON quotes (attribution_status, id) WHERE deleted_at IS NULL;
@@ -4,8 +4,86 @@ import (
 	"errors"
 )
 
+// Code classifies an Error so transport layers can map it to a response.
+type Code string
+
+const (
+	CodeInvalidArgument      Code = "invalid-argument"
+	CodeNotFound             Code = "not-found"
+	CodeValidation           Code = "validation"
+	CodeConflict             Code = "conflict"
+	CodePayloadTooLarge      Code = "payload-too-large"
+	CodeUnsupportedMediaType Code = "unsupported-media-type"
+	CodeInternal             Code = "internal"
+)
+
+// FieldError describes a problem with a single field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an application error with a Code, a message that is safe to show
+// to clients and, optionally, per-field problems and an underlying cause.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an Error with the given code and message.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap returns an Error with the given code and message caused by err.
+func Wrap(code Code, err error, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// Validation returns a CodeValidation error listing the field problems.
+func Validation(fields []FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: "validation failed", Fields: fields}
+}
+
+// As returns the first *Error in err's chain, if any.
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// CodeOf returns the code of the first *Error in err's chain,
+// or CodeInternal when err carries no code.
+func CodeOf(err error) Code {
+	if e, ok := As(err); ok {
+		return e.Code
+	}
+	return CodeInternal
+}
+
 var (
-	ErrQuoteNotFound = errors.New("no quote was found")
-	ErrExecDB        = errors.New("db exec error")
-	ErrQuery         = errors.New("db query error")
+	ErrQuoteNotFound    = New(CodeNotFound, "no quote was found")
+	ErrRevisionNotFound = New(CodeNotFound, "no quote revision was found")
+	ErrTagNotFound      = New(CodeNotFound, "no tag was found")
+	ErrTagExists        = New(CodeConflict, "a tag with this name already exists, merge the tags instead")
+	ErrAuthorNotFound   = New(CodeNotFound, "no author was found")
+	ErrAuthorExists     = New(CodeConflict, "another author already has this name or alias")
+	ErrAuthorHasQuotes  = New(CodeConflict, "the author still has quotes, including ones in the trash")
+	ErrExecDB           = New(CodeInternal, "db exec error")
+	ErrQuery            = New(CodeInternal, "db query error")
 )